@@ -0,0 +1,284 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+// Package client implements a client for the protocol spoken by the
+// daedalus server. It can be used to write solvers that run outside of
+// this program.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mem/labyrinth/mazelib"
+)
+
+var (
+	// ErrVictory is returned by Move when Icarus reaches the treasure
+	ErrVictory = mazelib.ErrVictory
+
+	// ErrWall is returned by Move when there's a wall in the
+	// requested direction
+	ErrWall = mazelib.ErrWall
+
+	// ErrOutOfBounds is returned by Move when the requested
+	// direction leads outside of the maze
+	ErrOutOfBounds = mazelib.ErrOutOfBounds
+
+	// ErrTooManyMoves is returned by Move when the client is moving
+	// faster than the server allows; it's fine to try again later
+	ErrTooManyMoves = mazelib.ErrTooManyMoves
+
+	// ErrTooManySessions is returned by Awake when the client is
+	// already playing as many mazes as the server allows
+	ErrTooManySessions = mazelib.ErrTooManySessions
+
+	// ErrSessionExpired is returned by Move when the server discarded
+	// the maze because it wasn't played for too long
+	ErrSessionExpired = mazelib.ErrSessionExpired
+
+	// ErrBudgetExhausted is returned by Move when the client has
+	// already taken the maximum number of steps allowed
+	ErrBudgetExhausted = errors.New("step budget exhausted")
+)
+
+// ServerError is returned when the server reports an error that
+// doesn't have a more specific meaning, or when the response can't be
+// understood. Status is the HTTP status of the response, if known.
+type ServerError struct {
+	Status  int
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	if e.Status == 0 {
+		return "server error: " + e.Message
+	}
+	return fmt.Sprintf("server error (%d): %s", e.Status, e.Message)
+}
+
+// Transport carries the requests made by a Client to a daedalus
+// server. Replies flagged as errors by the server should be returned
+// as they are, it's up to the client to interpret them.
+type Transport interface {
+	// Awake asks the server for a new maze
+	Awake(ctx context.Context) (mazelib.Reply, error)
+
+	// Move moves Icarus in direction dir
+	Move(ctx context.Context, dir int) (mazelib.Reply, error)
+
+	// MovePath moves Icarus along path, one step in each direction
+	MovePath(ctx context.Context, path []int) (mazelib.BatchReply, error)
+
+	// Done tells the server that the client is done
+	Done(ctx context.Context) error
+}
+
+// Client talks to a daedalus server using a Transport.
+//
+// A Client keeps track of the steps taken in the current maze, so it's
+// not safe for concurrent use.
+type Client struct {
+	// MaxSteps is the maximum number of steps allowed in a single
+	// maze. Zero means there's no limit.
+	MaxSteps int
+
+	t     Transport
+	steps int
+}
+
+// New creates a new client for the daedalus server at URL server.
+// Requests that take longer than timeout are aborted.
+func New(server string, timeout time.Duration) (*Client, error) {
+	t, err := NewHTTPTransport(server, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithTransport(t), nil
+}
+
+// NewWithTransport creates a new client that uses transport t to talk
+// to the server.
+func NewWithTransport(t Transport) *Client {
+	return &Client{t: t}
+}
+
+// Steps returns the number of steps taken in the current maze
+func (c *Client) Steps() int {
+	return c.steps
+}
+
+// Awake asks the server for a new maze and returns the survey of the
+// room where Icarus wakes up.
+func (c *Client) Awake(ctx context.Context) (mazelib.Survey, error) {
+	c.steps = 0
+
+	r, err := check(c.t.Awake(ctx))
+	return r.Survey, err
+}
+
+// Move moves Icarus in direction dir (one of mazelib.N, mazelib.S,
+// mazelib.E or mazelib.W) and returns the survey of the new room. If
+// the new room holds the treasure, it returns ErrVictory. If the server
+// says Icarus is moving too fast, Move waits and tries again.
+func (c *Client) Move(ctx context.Context, dir int) (mazelib.Survey, error) {
+	if mazelib.DirectionName(dir) == "" {
+		return mazelib.Survey{}, fmt.Errorf("invalid direction %d", dir)
+	}
+
+	if c.MaxSteps > 0 && c.steps >= c.MaxSteps {
+		return mazelib.Survey{}, ErrBudgetExhausted
+	}
+
+	var r mazelib.Reply
+	err := retry(ctx, func() (err error) {
+		r, err = check(c.t.Move(ctx, dir))
+		return err
+	})
+	if err != nil {
+		return r.Survey, err
+	}
+
+	c.steps++
+
+	if r.Victory {
+		return r.Survey, ErrVictory
+	}
+
+	return r.Survey, nil
+}
+
+// MovePath moves Icarus along path, which is a list of directions,
+// making a single request to the server. It returns the survey of each
+// room Icarus moved into. If Icarus couldn't follow the whole path, it
+// returns the error for the move that failed, or ErrVictory if he found
+// the treasure on the way. Like Move, it waits and carries on if the
+// server says Icarus is moving too fast.
+func (c *Client) MovePath(ctx context.Context, path []int) ([]mazelib.Survey, error) {
+	for _, dir := range path {
+		if mazelib.DirectionName(dir) == "" {
+			return nil, fmt.Errorf("invalid direction %d", dir)
+		}
+	}
+
+	var budgetErr error
+	if c.MaxSteps > 0 && c.steps+len(path) > c.MaxSteps {
+		path = path[:c.MaxSteps-c.steps]
+		budgetErr = ErrBudgetExhausted
+	}
+
+	if len(path) == 0 {
+		return nil, budgetErr
+	}
+
+	var surveys []mazelib.Survey
+	err := retry(ctx, func() error {
+		s, err := c.movePath(ctx, path)
+		surveys = append(surveys, s...)
+		path = path[len(s):]
+		return err
+	})
+	if err != nil {
+		return surveys, err
+	}
+
+	return surveys, budgetErr
+}
+
+// movePath makes a single request to move Icarus along path
+func (c *Client) movePath(ctx context.Context, path []int) ([]mazelib.Survey, error) {
+	b, err := c.t.MovePath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Taken > len(b.Replies) {
+		return nil, &ServerError{Message: "invalid reply: more moves taken than replies"}
+	}
+
+	c.steps += b.Taken
+
+	surveys := make([]mazelib.Survey, b.Taken)
+	for i, r := range b.Replies[:b.Taken] {
+		surveys[i] = r.Survey
+	}
+
+	switch {
+	case b.Taken < len(b.Replies):
+		if _, err := check(b.Replies[b.Taken], nil); err != nil {
+			return surveys, err
+		}
+		return surveys, &ServerError{Message: "invalid reply: move failed without an error"}
+
+	case b.Taken > 0 && b.Replies[b.Taken-1].Victory:
+		return surveys, ErrVictory
+
+	case b.Taken < len(path):
+		return surveys, &ServerError{Message: "invalid reply: path stopped early"}
+	}
+
+	return surveys, nil
+}
+
+// Done tells the server that the client is done solving mazes
+func (c *Client) Done(ctx context.Context) error {
+	return c.t.Done(ctx)
+}
+
+// retry calls f until it doesn't fail with ErrTooManyMoves or ctx is
+// done, waiting a little longer each time
+func retry(ctx context.Context, f func() error) error {
+	wait := 50 * time.Millisecond
+
+	for {
+		err := f()
+		if err != ErrTooManyMoves {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		if wait < time.Second {
+			wait *= 2
+		}
+	}
+}
+
+// check turns replies flagged as errors into one of the errors defined
+// in this package
+func check(r mazelib.Reply, err error) (mazelib.Reply, error) {
+	if err != nil {
+		return r, err
+	}
+
+	if !r.Error {
+		return r, nil
+	}
+
+	for _, err := range []error{ErrVictory, ErrWall, ErrOutOfBounds, ErrTooManyMoves, ErrTooManySessions, ErrSessionExpired} {
+		if r.Message == err.Error() {
+			return r, err
+		}
+	}
+
+	return r, &ServerError{Message: r.Message}
+}
@@ -0,0 +1,266 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/mem/labyrinth/mazelib"
+)
+
+// fakeTransport plays a maze that is a single corridor going east,
+// with Icarus waking up at the west end and the treasure at the east
+// end. Moves whose number is in busy are refused as too fast.
+type fakeTransport struct {
+	length int
+	x      int
+	moves  int
+	busy   map[int]bool
+	paths  [][]int
+}
+
+func (t *fakeTransport) survey() mazelib.Survey {
+	return mazelib.Survey{Top: true, Bottom: true, Left: t.x == 0, Right: t.x == t.length-1}
+}
+
+func (t *fakeTransport) move(dir int) mazelib.Reply {
+	t.moves++
+	if t.busy[t.moves] {
+		return mazelib.Reply{Error: true, Message: ErrTooManyMoves.Error()}
+	}
+
+	switch {
+	case dir == mazelib.E && t.x < t.length-1:
+		t.x++
+	case dir == mazelib.W && t.x > 0:
+		t.x--
+	default:
+		return mazelib.Reply{Survey: t.survey(), Error: true, Message: ErrWall.Error()}
+	}
+
+	return mazelib.Reply{Survey: t.survey(), Victory: t.x == t.length-1}
+}
+
+func (t *fakeTransport) Awake(ctx context.Context) (mazelib.Reply, error) {
+	t.x = 0
+	return mazelib.Reply{Survey: t.survey()}, nil
+}
+
+func (t *fakeTransport) Move(ctx context.Context, dir int) (mazelib.Reply, error) {
+	return t.move(dir), nil
+}
+
+// MovePath stops at the first move that fails or finds the treasure,
+// like daedalus does
+func (t *fakeTransport) MovePath(ctx context.Context, path []int) (mazelib.BatchReply, error) {
+	t.paths = append(t.paths, append([]int(nil), path...))
+
+	var b mazelib.BatchReply
+	for _, dir := range path {
+		r := t.move(dir)
+		b.Replies = append(b.Replies, r)
+		if r.Error {
+			break
+		}
+		b.Taken++
+		if r.Victory {
+			break
+		}
+	}
+
+	return b, nil
+}
+
+func (t *fakeTransport) Done(ctx context.Context) error {
+	return nil
+}
+
+func east(n int) []int {
+	path := make([]int, n)
+	for i := range path {
+		path[i] = mazelib.E
+	}
+	return path
+}
+
+func TestMovePathBudget(t *testing.T) {
+	tests := []struct {
+		name           string
+		max, before    int
+		path, sent     []int
+		surveys, steps int
+		err            error
+	}{
+		{"no limit", 0, 2, east(3), east(3), 3, 5, nil},
+		{"within the budget", 10, 2, east(3), east(3), 3, 5, nil},
+		{"budget reached exactly", 5, 2, east(3), east(3), 3, 5, nil},
+		{"trimmed", 5, 3, east(4), east(2), 2, 5, ErrBudgetExhausted},
+		{"nothing left", 3, 3, east(2), nil, 0, 3, ErrBudgetExhausted},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		tr := &fakeTransport{length: 20}
+		c := NewWithTransport(tr)
+		c.MaxSteps = test.max
+		c.Awake(ctx)
+		for i := 0; i < test.before; i++ {
+			if _, err := c.Move(ctx, mazelib.E); err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+		}
+
+		surveys, err := c.MovePath(ctx, test.path)
+		if err != test.err {
+			t.Errorf("%s: error %v, expecting %v", test.name, err, test.err)
+		}
+		if len(surveys) != test.surveys {
+			t.Errorf("%s: %d surveys, expecting %d", test.name, len(surveys), test.surveys)
+		}
+		if c.Steps() != test.steps {
+			t.Errorf("%s: %d steps, expecting %d", test.name, c.Steps(), test.steps)
+		}
+
+		var sent []int
+		if len(tr.paths) > 0 {
+			sent = tr.paths[0]
+		}
+		if !reflect.DeepEqual(sent, test.sent) {
+			t.Errorf("%s: sent path %v, expecting %v", test.name, sent, test.sent)
+		}
+
+		// once the budget is used up, nothing else is sent
+		if test.max > 0 && c.Steps() == test.max {
+			moves := tr.moves
+			if _, err := c.Move(ctx, mazelib.E); err != ErrBudgetExhausted {
+				t.Errorf("%s: moving past the budget: %v", test.name, err)
+			}
+			if tr.moves != moves {
+				t.Errorf("%s: move sent past the budget", test.name)
+			}
+		}
+	}
+}
+
+func TestMovePathPartial(t *testing.T) {
+	tests := []struct {
+		name    string
+		length  int
+		path    []int
+		surveys int
+		err     error
+	}{
+		{"whole path", 10, east(3), 3, nil},
+		{"wall", 10, []int{mazelib.E, mazelib.E, mazelib.N, mazelib.E}, 2, ErrWall},
+		{"wall first", 10, []int{mazelib.W, mazelib.E}, 0, ErrWall},
+		{"treasure", 3, east(4), 2, ErrVictory},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		tr := &fakeTransport{length: test.length}
+		c := NewWithTransport(tr)
+		c.Awake(ctx)
+
+		surveys, err := c.MovePath(ctx, test.path)
+		if err != test.err {
+			t.Errorf("%s: error %v, expecting %v", test.name, err, test.err)
+		}
+		if len(surveys) != test.surveys {
+			t.Errorf("%s: %d surveys, expecting %d", test.name, len(surveys), test.surveys)
+		}
+		if c.Steps() != test.surveys {
+			t.Errorf("%s: %d steps, expecting %d", test.name, c.Steps(), test.surveys)
+		}
+		if len(surveys) > 0 && surveys[len(surveys)-1] != tr.survey() {
+			t.Errorf("%s: last survey %+v, expecting %+v", test.name, surveys[len(surveys)-1], tr.survey())
+		}
+	}
+}
+
+func TestRetryTooManyMoves(t *testing.T) {
+	ctx := context.Background()
+
+	tr := &fakeTransport{length: 10, busy: map[int]bool{1: true, 2: true}}
+	c := NewWithTransport(tr)
+	c.Awake(ctx)
+	if _, err := c.Move(ctx, mazelib.E); err != nil {
+		t.Fatal(err)
+	}
+	if tr.moves != 3 || c.Steps() != 1 || tr.x != 1 {
+		t.Errorf("sent %d moves and took %d steps to room %d, expecting 3, 1 and 1", tr.moves, c.Steps(), tr.x)
+	}
+
+	// the rest of the path is sent again after the refused move
+	tr = &fakeTransport{length: 10, busy: map[int]bool{2: true}}
+	c = NewWithTransport(tr)
+	c.Awake(ctx)
+	surveys, err := c.MovePath(ctx, east(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(surveys) != 3 || c.Steps() != 3 || tr.x != 3 {
+		t.Errorf("%d surveys and %d steps to room %d, expecting 3, 3 and 3", len(surveys), c.Steps(), tr.x)
+	}
+	if want := [][]int{east(3), east(2)}; !reflect.DeepEqual(tr.paths, want) {
+		t.Errorf("sent paths %v, expecting %v", tr.paths, want)
+	}
+
+	// giving up when the context is done
+	tr = &fakeTransport{length: 10, busy: map[int]bool{1: true}}
+	c = NewWithTransport(tr)
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := c.Move(ctx, mazelib.E); err != ErrTooManyMoves {
+		t.Errorf("error %v, expecting %v", err, ErrTooManyMoves)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	errTransport := errors.New("connection refused")
+
+	tests := []struct {
+		reply mazelib.Reply
+		err   error
+	}{
+		{mazelib.Reply{}, nil},
+		{mazelib.Reply{Victory: true}, nil},
+		{mazelib.Reply{Error: true, Message: mazelib.ErrVictory.Error()}, ErrVictory},
+		{mazelib.Reply{Error: true, Message: mazelib.ErrWall.Error()}, ErrWall},
+		{mazelib.Reply{Error: true, Message: mazelib.ErrOutOfBounds.Error()}, ErrOutOfBounds},
+		{mazelib.Reply{Error: true, Message: mazelib.ErrTooManyMoves.Error()}, ErrTooManyMoves},
+		{mazelib.Reply{Error: true, Message: mazelib.ErrTooManySessions.Error()}, ErrTooManySessions},
+		{mazelib.Reply{Error: true, Message: mazelib.ErrSessionExpired.Error()}, ErrSessionExpired},
+	}
+
+	for _, test := range tests {
+		if _, err := check(test.reply, nil); err != test.err {
+			t.Errorf("%+v: error %v, expecting %v", test.reply, err, test.err)
+		}
+	}
+
+	_, err := check(mazelib.Reply{Error: true, Message: "out of coffee"}, nil)
+	if se, ok := err.(*ServerError); !ok || se.Message != "out of coffee" {
+		t.Errorf("unknown error: %v, expecting a server error", err)
+	}
+
+	if _, err := check(mazelib.Reply{}, errTransport); err != errTransport {
+		t.Errorf("transport error: %v, expecting %v", err, errTransport)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mem/labyrinth/mazelib"
+)
+
+// HTTPTransport talks to a daedalus server over HTTP. The server is
+// identified by a base URL, which may include a path in case daedalus
+// is running behind a reverse proxy.
+type HTTPTransport struct {
+	// Token is sent as a bearer token with every request, if it's
+	// not empty
+	Token string
+
+	base *url.URL
+	http *http.Client
+}
+
+// NewHTTPTransport creates a new transport for the daedalus server at
+// URL server. Requests that take longer than timeout are aborted.
+func NewHTTPTransport(server string, timeout time.Duration) (*HTTPTransport, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid server URL %q: unsupported scheme", server)
+	}
+
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid server URL %q: missing host", server)
+	}
+
+	return &HTTPTransport{
+		base: u,
+		http: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// URL returns the URL for the endpoint at path p, relative to the base
+// URL of the server.
+func (t *HTTPTransport) URL(p string) string {
+	u := *t.base
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(p, "/")
+	return u.String()
+}
+
+// Awake implements Transport
+func (t *HTTPTransport) Awake(ctx context.Context) (mazelib.Reply, error) {
+	return t.get(ctx, "/awake")
+}
+
+// Move implements Transport
+func (t *HTTPTransport) Move(ctx context.Context, dir int) (mazelib.Reply, error) {
+	return t.get(ctx, "/move/"+mazelib.DirectionName(dir))
+}
+
+// MovePath implements Transport
+func (t *HTTPTransport) MovePath(ctx context.Context, path []int) (mazelib.BatchReply, error) {
+	var b mazelib.BatchReply
+
+	names := make([]string, len(path))
+	for i, dir := range path {
+		names[i] = mazelib.DirectionName(dir)
+	}
+
+	body, err := json.Marshal(names)
+	if err != nil {
+		return b, err
+	}
+
+	err = t.do(ctx, "POST", "/moves", body, &b)
+	return b, err
+}
+
+// Done implements Transport
+func (t *HTTPTransport) Done(ctx context.Context) error {
+	_, err := t.get(ctx, "/done")
+	return err
+}
+
+// Wait waits until the server reports that it's ready to accept
+// requests, or ctx is done.
+func (t *HTTPTransport) Wait(ctx context.Context) error {
+	for {
+		req, err := http.NewRequest("GET", t.URL("/healthz"), nil)
+		if err != nil {
+			return err
+		}
+
+		response, err := t.http.Do(req.WithContext(ctx))
+		if err == nil {
+			response.Body.Close()
+			if response.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// get makes a request to the endpoint at path p and decodes the reply
+func (t *HTTPTransport) get(ctx context.Context, p string) (mazelib.Reply, error) {
+	var r mazelib.Reply
+	err := t.do(ctx, "GET", p, nil, &r)
+	return r, err
+}
+
+// do makes a request to the endpoint at path p and decodes the reply
+// into v. Replies with an error status that can't be decoded as
+// mazelib.Reply are reported as a ServerError.
+func (t *HTTPTransport) do(ctx context.Context, method, p string, body []byte, v interface{}) error {
+	req, err := http.NewRequest(method, t.URL(p), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if t.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+t.Token)
+	}
+
+	response, err := t.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		// the server reports errors using mazelib.Reply, no
+		// matter what the caller is expecting
+		var r mazelib.Reply
+		if err := json.Unmarshal(contents, &r); err != nil || !r.Error {
+			return &ServerError{
+				Status:  response.StatusCode,
+				Message: http.StatusText(response.StatusCode),
+			}
+		}
+
+		if rp, ok := v.(*mazelib.Reply); ok {
+			// let the client interpret the error
+			*rp = r
+			return nil
+		}
+
+		_, err := check(r, nil)
+		if se, ok := err.(*ServerError); ok {
+			se.Status = response.StatusCode
+		}
+		return err
+	}
+
+	if err := json.Unmarshal(contents, v); err != nil {
+		return &ServerError{
+			Status:  response.StatusCode,
+			Message: fmt.Sprintf("invalid reply: %v", err),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mem/labyrinth/mazelib"
+)
+
+// WebSocketTransport talks to a daedalus server over a websocket
+// connection, which saves making an HTTP request for every step. The
+// server creates a maze for each connection, so unlike the HTTP
+// transport, several of these can be used at the same time. Calling
+// Done only ends the session, it doesn't shut down the server.
+type WebSocketTransport struct {
+	conn    *websocket.Conn
+	timeout time.Duration
+}
+
+// DialWebSocket connects to the daedalus server at URL server, which
+// must use the ws or wss scheme (for example ws://127.0.0.1:8013/ws).
+// If token isn't empty, it's sent as a bearer token. Requests that take
+// longer than timeout are aborted.
+func DialWebSocket(ctx context.Context, server, token string, timeout time.Duration) (*WebSocketTransport, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return nil, fmt.Errorf("invalid server URL %q: unsupported scheme", server)
+	}
+
+	var header http.Header
+	if token != "" {
+		header = http.Header{"Authorization": {"Bearer " + token}}
+	}
+
+	dialer := websocket.Dialer{HandshakeTimeout: timeout}
+	conn, resp, err := dialer.DialContext(ctx, server, header)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			return nil, &ServerError{Status: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		}
+		return nil, err
+	}
+
+	return &WebSocketTransport{conn: conn, timeout: timeout}, nil
+}
+
+// Awake implements Transport
+func (t *WebSocketTransport) Awake(ctx context.Context) (mazelib.Reply, error) {
+	var r mazelib.Reply
+	err := t.do(ctx, mazelib.Request{Action: "awake"}, &r)
+	return r, err
+}
+
+// Move implements Transport
+func (t *WebSocketTransport) Move(ctx context.Context, dir int) (mazelib.Reply, error) {
+	var r mazelib.Reply
+	err := t.do(ctx, mazelib.Request{Action: "move", Direction: mazelib.DirectionName(dir)}, &r)
+	return r, err
+}
+
+// MovePath implements Transport
+func (t *WebSocketTransport) MovePath(ctx context.Context, path []int) (mazelib.BatchReply, error) {
+	var b mazelib.BatchReply
+
+	names := make([]string, len(path))
+	for i, dir := range path {
+		names[i] = mazelib.DirectionName(dir)
+	}
+
+	err := t.do(ctx, mazelib.Request{Action: "moves", Path: names}, &b)
+	return b, err
+}
+
+// Done implements Transport. It ends the session and closes the
+// connection.
+func (t *WebSocketTransport) Done(ctx context.Context) error {
+	var r mazelib.Reply
+	err := t.do(ctx, mazelib.Request{Action: "done"}, &r)
+	t.conn.Close()
+	return err
+}
+
+// do sends request req and decodes the reply into v
+func (t *WebSocketTransport) do(ctx context.Context, req mazelib.Request, v interface{}) error {
+	deadline := time.Time{}
+	if t.timeout > 0 {
+		deadline = time.Now().Add(t.timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+
+	t.conn.SetWriteDeadline(deadline)
+	if err := t.conn.WriteJSON(req); err != nil {
+		return err
+	}
+
+	t.conn.SetReadDeadline(deadline)
+	return t.conn.ReadJSON(v)
+}
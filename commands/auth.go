@@ -0,0 +1,211 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mem/labyrinth/daedaluspb"
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiToken is a token clients can use to authenticate with daedalus.
+// The tokens are listed in the config file under "tokens":
+//
+//	tokens:
+//	  - token: 9c1b0e0b7f
+//	    name: alice
+//	  - token: 4d2a6f3e51
+//	    name: ops
+//	    admin: true
+//
+// Clients using a token are known by its name, no matter what name
+// they ask for. Only admins can shut down the server.
+type apiToken struct {
+	Token string
+	Name  string
+	Admin bool
+}
+
+// tokens are the tokens accepted by the server. If there are none,
+// authentication is disabled.
+var tokens []apiToken
+
+// identityKey is the key used to store the token of the client in the
+// context of gRPC calls
+type identityKey struct{}
+
+// The messages sent to clients that can't do what they asked for
+const (
+	msgUnauthorized = "unauthorized"
+	msgForbidden    = "forbidden"
+)
+
+// loadTokens reads the tokens from the config file
+func loadTokens() error {
+	var list []apiToken
+	if err := viper.UnmarshalKey("tokens", &list); err != nil {
+		return fmt.Errorf("invalid tokens: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, t := range list {
+		if t.Token == "" || t.Name == "" {
+			return fmt.Errorf("invalid tokens: entry %d needs a token and a name", i+1)
+		}
+		if seen[t.Token] {
+			return fmt.Errorf("invalid tokens: entry %d repeats a token", i+1)
+		}
+		seen[t.Token] = true
+	}
+
+	tokens = list
+	if len(tokens) > 0 {
+		logger.Info("authentication enabled", "tokens", len(tokens))
+	}
+
+	return nil
+}
+
+// lookupToken returns the token matching the authorization value auth,
+// which should be of the form "Bearer <token>"
+func lookupToken(auth string) (apiToken, bool) {
+	const prefix = "bearer "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return apiToken{}, false
+	}
+	given := []byte(strings.TrimSpace(auth[len(prefix):]))
+
+	for _, t := range tokens {
+		if subtle.ConstantTimeCompare(given, []byte(t.Token)) == 1 {
+			return t, true
+		}
+	}
+
+	return apiToken{}, false
+}
+
+// authenticate is a middleware that rejects the requests that don't
+// carry a valid token, if authentication is enabled. Since browsers
+// can't add headers to some requests, like the ones made by the viewer
+// to follow the events, the token can also be given in the token query
+// parameter.
+func authenticate(c *gin.Context) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	auth := c.GetHeader("Authorization")
+	if auth == "" && c.Query("token") != "" {
+		auth = "Bearer " + c.Query("token")
+	}
+
+	t, ok := lookupToken(auth)
+	if !ok {
+		c.Header("WWW-Authenticate", `Bearer realm="daedalus"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, mazelib.Reply{Error: true, Message: msgUnauthorized})
+		return
+	}
+
+	c.Set("identity", t)
+}
+
+// requireAdmin is a middleware that only lets admins through, if
+// authentication is enabled. It must come after authenticate.
+func requireAdmin(c *gin.Context) {
+	if len(tokens) == 0 {
+		return
+	}
+
+	if t, _ := c.Get("identity"); !t.(apiToken).Admin {
+		c.AbortWithStatusJSON(http.StatusForbidden, mazelib.Reply{Error: true, Message: msgForbidden})
+	}
+}
+
+// identity returns the token used by the client making request c, if
+// any
+func identity(c *gin.Context) (apiToken, bool) {
+	t, ok := c.Get("identity")
+	if !ok {
+		return apiToken{}, false
+	}
+	return t.(apiToken), true
+}
+
+// grpcAuthenticate checks the token in the authorization metadata of a
+// gRPC call, if authentication is enabled, and returns a context that
+// carries it. Only admins can call Done.
+func grpcAuthenticate(ctx context.Context, method string) (context.Context, error) {
+	if len(tokens) == 0 {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	auth := md.Get("authorization")
+	if len(auth) == 0 {
+		return nil, status.Error(codes.Unauthenticated, msgUnauthorized)
+	}
+
+	t, ok := lookupToken(auth[0])
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, msgUnauthorized)
+	}
+
+	if method == daedaluspb.Daedalus_Done_FullMethodName && !t.Admin {
+		return nil, status.Error(codes.PermissionDenied, msgForbidden)
+	}
+
+	return context.WithValue(ctx, identityKey{}, t), nil
+}
+
+// authStream is a server stream with an authenticated context
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implements grpc.ServerStream
+func (s authStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcUnaryAuth authenticates unary gRPC calls
+func grpcUnaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := grpcAuthenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// grpcStreamAuth authenticates streaming gRPC calls
+func grpcStreamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := grpcAuthenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, authStream{ss, ctx})
+}
@@ -0,0 +1,63 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthenticateWatching(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tokens = []apiToken{{Token: "player", Name: "alice"}, {Token: "admin", Name: "ops", Admin: true}}
+	defer func() { tokens = nil }()
+
+	r := gin.New()
+	watch := r.Group("/", authenticate, requireAdmin)
+	watch.GET("/events", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		url, auth string
+		status    int
+	}{
+		{"/events", "", http.StatusUnauthorized},
+		{"/events", "Bearer nobody", http.StatusUnauthorized},
+		{"/events", "Bearer player", http.StatusForbidden},
+		{"/events", "Bearer admin", http.StatusOK},
+		{"/events?token=player", "", http.StatusForbidden},
+		{"/events?token=admin", "", http.StatusOK},
+		{"/events?session=2&token=admin", "", http.StatusOK},
+		{"/events?token=admin", "Bearer player", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.url, nil)
+		if test.auth != "" {
+			req.Header.Set("Authorization", test.auth)
+		}
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("%s with %q: status %d, expecting %d", test.url, test.auth, w.Code, test.status)
+		}
+	}
+}
@@ -17,13 +17,17 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -33,22 +37,37 @@ import (
 )
 
 type Maze struct {
+	id         int
+	client     string
+	builder    string
+	file       string // the maze was loaded from this file
+	seed       int64
 	rooms      [][]mazelib.Room
 	start      mazelib.Coordinate
 	end        mazelib.Coordinate
 	icarus     mazelib.Coordinate
 	StepsTaken int
+	lastActive time.Time
 }
 
 // Tracking the current maze being solved
 
-// WARNING: This approach is not safe for concurrent use
-// This server is only intended to have a single client at a time
-// We would need a different and more complex approach if we wanted
-// concurrent connections than these simple package variables
+// WARNING: The REST API is not safe for concurrent use
+// The maze used by the REST API is shared by all the clients, so it's
+// only intended to have a single client at a time. Clients connecting
+// over a websocket get a maze of their own.
 var currentMaze *Maze
 var scores []int
 
+// mu protects currentMaze, scores and the tracker used to pick maze
+// builders, which are shared by the REST and websocket clients
+var mu sync.Mutex
+
+var errNoMaze = errors.New("no maze, Icarus has to wake up first")
+
+// done is used by the handlers to ask the server to shut down
+var done chan struct{}
+
 // Defining the daedalus command.
 // This will be called as 'laybrinth daedalus'
 var daedalusCmd = &cobra.Command{
@@ -60,7 +79,10 @@ var daedalusCmd = &cobra.Command{
 
   Daedalus runs a server which Icarus clients can connect to to solve laybrinths.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunServer()
+		if err := RunServer(nil); err != nil {
+			logger.Error("daedalus failed", "err", err)
+			os.Exit(-1)
+		}
 	},
 }
 
@@ -68,114 +90,359 @@ func init() {
 	rand.Seed(time.Now().UTC().UnixNano()) // need to initialize the seed
 	gin.SetMode(gin.ReleaseMode)
 
+	daedalusCmd.Flags().Int("grpc-port", 0, "Port to serve the gRPC API on (0 disables it)")
+	viper.BindPFlag("grpc-port", daedalusCmd.Flags().Lookup("grpc-port"))
+
+	daedalusCmd.Flags().Duration("session-idle", 10*time.Minute, "Discard mazes that aren't played for this long (0 keeps them forever)")
+	viper.BindPFlag("session-idle", daedalusCmd.Flags().Lookup("session-idle"))
+
+	daedalusCmd.Flags().Int("max-sessions", 0, "Maximum number of mazes each client can play at the same time (0 means no limit)")
+	viper.BindPFlag("max-sessions", daedalusCmd.Flags().Lookup("max-sessions"))
+
+	daedalusCmd.Flags().Float64("move-rate", 0, "Maximum number of moves per second for each client (0 means no limit)")
+	viper.BindPFlag("move-rate", daedalusCmd.Flags().Lookup("move-rate"))
+
 	RootCmd.AddCommand(daedalusCmd)
 }
 
-// Runs the web server
-func RunServer() {
+// Runs the web server. Once the server is listening, its address is
+// sent on ready, unless ready is nil. The server keeps running until a
+// client calls /done or the process is interrupted, at which point it
+// shuts down gracefully and prints the results.
+func RunServer(ready chan<- net.Addr) error {
+	// Using gin-gonic/gin to handle our routing
+	r := gin.New()
+	r.Use(gin.Recovery(), logRequests, measure)
+	v1 := r.Group("/")
+	{
+		// playing needs a token, if authentication is enabled,
+		// and only admins can shut down the server
+		play := v1.Group("/", authenticate)
+		play.GET("/awake", GetStartingPoint)
+		play.GET("/move/:direction", MoveDirection)
+		play.POST("/moves", MovePath)
+		play.GET("/ws", Play)
+		play.GET("/done", requireAdmin, End)
+
+		// spectators see where the treasure is, so watching is
+		// for admins only
+		watch := v1.Group("/", authenticate, requireAdmin)
+		watch.GET("/events", Events)
+		watch.GET("/viewer", Viewer)
+
+		v1.GET("/healthz", Healthz)
+		v1.GET("/metrics", Metrics)
+		v1.GET("/leaderboard", Leaderboard)
+	}
+
+	if err := loadTokens(); err != nil {
+		return err
+	}
+
+	if err := loadMazeFiles(); err != nil {
+		return err
+	}
+
+	if err := openHistory(); err != nil {
+		return err
+	}
+	defer closeHistory()
+
+	// Listen before serving so that a port of 0 picks any available
+	// port, and so that ready is signalled only once clients can
+	// connect.
+	l, err := net.Listen("tcp", ":"+viper.GetString("port"))
+	if err != nil {
+		return err
+	}
+
+	logger.Info("listening", "addr", l.Addr().String())
+
+	done = make(chan struct{}, 1)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go expireIdle(stop)
+
 	// Adding handling so that even when ctrl+c is pressed we still print
 	// out the results prior to exiting.
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
+	defer signal.Stop(c)
+
+	gs, err := startGRPC()
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	srv := &http.Server{Handler: r}
+	srv.RegisterOnShutdown(closeSpectators)
+	errc := make(chan error, 1)
 	go func() {
-		<-c
-		printResults()
-		os.Exit(1)
+		errc <- srv.Serve(l)
 	}()
 
-	// Using gin-gonic/gin to handle our routing
-	r := gin.Default()
-	v1 := r.Group("/")
-	{
-		v1.GET("/awake", GetStartingPoint)
-		v1.GET("/move/:direction", MoveDirection)
-		v1.GET("/done", End)
+	if ready != nil {
+		ready <- l.Addr()
 	}
 
-	r.Run(":" + viper.GetString("port"))
+	select {
+	case err := <-errc:
+		if gs != nil {
+			gs.Stop()
+		}
+		return err
+	case <-done:
+	case <-c:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = srv.Shutdown(ctx)
+	if gs != nil {
+		stopGRPC(ctx, gs)
+	}
+
+	printResults()
+	closeRecordings()
+
+	return err
+}
+
+// Reports that the server is up and running
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
-// Ends a session and prints the results.
+// Ends a session and asks the server to shut down, which prints the
+// results.
 // Called by Icarus when he has reached
 //   the number of times he wants to solve the laybrinth.
 func End(c *gin.Context) {
-	printResults()
-	os.Exit(1)
+	c.JSON(http.StatusOK, mazelib.Reply{Message: "Goodbye"})
+	shutdown()
+}
+
+// shutdown asks the server to shut down once the pending requests are
+// done
+func shutdown() {
+	select {
+	case done <- struct{}{}:
+	default:
+	}
 }
 
 // initializes a new maze and places Icarus in his awakening location
 func GetStartingPoint(c *gin.Context) {
-	initializeMaze()
-	startRoom, err := currentMaze.Discover(currentMaze.Icarus())
-	if err != nil {
-		fmt.Println("Icarus is outside of the maze. This shouldn't ever happen")
-		fmt.Println(err)
-		os.Exit(-1)
+	mu.Lock()
+	defer mu.Unlock()
+
+	m, err := newSession(currentMaze, clientName(c))
+	currentMaze = m
+	switch {
+	case err == mazelib.ErrTooManySessions:
+		c.JSON(http.StatusTooManyRequests, mazelib.Reply{Error: true, Message: err.Error()})
+		return
+	case err != nil:
+		logger.Error("can't create maze", "err", err)
+		c.JSON(http.StatusInternalServerError, mazelib.Reply{Error: true, Message: err.Error()})
+		return
 	}
-	if viper.GetBool("pretty") {
-		mazelib.PrintPrettyMaze(currentMaze)
-	} else {
-		mazelib.PrintMaze(currentMaze)
+
+	c.JSON(startingPoint(currentMaze))
+}
+
+// startingPoint returns the HTTP status and the reply telling Icarus
+// what his awakening location in maze m looks like
+func startingPoint(m *Maze) (int, mazelib.Reply) {
+	startRoom, err := m.Discover(m.Icarus())
+	if err != nil {
+		sessionLogger(m).Error("Icarus is outside of the maze. This shouldn't ever happen", "err", err)
+		return http.StatusInternalServerError, mazelib.Reply{Error: true, Message: err.Error()}
 	}
 
-	c.JSON(http.StatusOK, mazelib.Reply{Survey: startRoom})
+	logMaze(m)
+
+	return http.StatusOK, mazelib.Reply{Survey: startRoom}
 }
 
 // The API response to the /move/:direction address
 func MoveDirection(c *gin.Context) {
-	var err error
+	dir, err := mazelib.ParseDirection(c.Param("direction"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, mazelib.Reply{Error: true, Message: err.Error()})
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
 
-	switch c.Param("direction") {
-	case "left":
-		err = currentMaze.MoveLeft()
-	case "right":
-		err = currentMaze.MoveRight()
-	case "down":
-		err = currentMaze.MoveDown()
-	case "up":
-		err = currentMaze.MoveUp()
+	status, r := moveIcarus(currentMaze, dir)
+	if status == http.StatusTooManyRequests {
+		c.Header("Retry-After", "1")
 	}
+	c.JSON(status, r)
+}
 
-	var r mazelib.Reply
+// The API response to the /moves address
+//
+// The body is either a JSON array of directions, as used by
+// /move/:direction, or a JSON string with a compact path, like "RRD"
+// (see mazelib.ParsePath). Empty paths are refused.
+func MovePath(c *gin.Context) {
+	var body json.RawMessage
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, mazelib.Reply{Error: true, Message: err.Error()})
+		return
+	}
 
+	path, err := parsePath(body)
+	if err == nil && len(path) == 0 {
+		// null, "" or []
+		err = errors.New("empty path")
+	}
 	if err != nil {
+		c.JSON(http.StatusBadRequest, mazelib.Reply{Error: true, Message: err.Error()})
+		return
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	b := moveIcarusPath(currentMaze, path)
+	if b.Taken == 0 && len(b.Replies) > 0 && b.Replies[0].Message == mazelib.ErrTooManyMoves.Error() {
+		// nothing was done, so say so like a single move would
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusTooManyRequests, b.Replies[0])
+		return
+	}
+
+	c.JSON(http.StatusOK, b)
+}
+
+// parsePath parses the body of a request to /moves
+func parsePath(body []byte) ([]int, error) {
+	var compact string
+	if err := json.Unmarshal(body, &compact); err == nil {
+		return mazelib.ParsePath(compact)
+	}
+
+	var names []string
+	if err := json.Unmarshal(body, &names); err != nil {
+		return nil, errors.New("path must be a string or an array of directions")
+	}
+
+	return parseDirections(names)
+}
+
+// parseDirections returns the directions corresponding to names
+func parseDirections(names []string) ([]int, error) {
+	path := make([]int, 0, len(names))
+	for _, name := range names {
+		dir, err := mazelib.ParseDirection(name)
+		if err != nil {
+			return nil, err
+		}
+		path = append(path, dir)
+	}
+
+	return path, nil
+}
+
+// moveIcarusPath moves Icarus along path in maze m, stopping at the
+// first move that fails or once the treasure is found.
+func moveIcarusPath(m *Maze, path []int) mazelib.BatchReply {
+	b := mazelib.BatchReply{Replies: make([]mazelib.Reply, 0, len(path))}
+
+	for _, dir := range path {
+		_, r := moveIcarus(m, dir)
+		b.Replies = append(b.Replies, r)
+		if r.Error {
+			break
+		}
+		b.Taken++
+		if r.Victory {
+			break
+		}
+	}
+
+	return b
+}
+
+// moveIcarus moves Icarus in direction dir in maze m and returns the
+// reply for the client along with the corresponding HTTP status
+func moveIcarus(m *Maze, dir int) (int, mazelib.Reply) {
+	var r mazelib.Reply
+	var err error
+
+	if m == nil {
+		countMove(false)
+		r.Error = true
+		r.Message = errNoMaze.Error()
+		return http.StatusConflict, r
+	}
+
+	if !active(m) {
+		countMove(false)
+		r.Error = true
+		r.Message = mazelib.ErrSessionExpired.Error()
+		return http.StatusGone, r
+	}
+
+	if !allowMove(m.client) {
+		countMove(false)
+		r.Error = true
+		r.Message = mazelib.ErrTooManyMoves.Error()
+		return http.StatusTooManyRequests, r
+	}
+
+	m.lastActive = clock()
+
+	if err = m.Move(dir); err != nil {
+		countMove(false)
+		moveEvent(m, dir, false, err)
 		r.Error = true
 		r.Message = err.Error()
-		c.JSON(409, r)
-		return
+		return http.StatusConflict, r
 	}
 
-	s, e := currentMaze.LookAround()
+	s, e := m.LookAround()
+
+	countMove(true)
+	moveEvent(m, dir, e == mazelib.ErrVictory, nil)
 
 	if e != nil {
 		if e == mazelib.ErrVictory {
-			scores = append(scores, currentMaze.StepsTaken)
+			scores = append(scores, m.StepsTaken)
+			countVictory(m)
+			recordResult(m)
+			sessionLogger(m).Info("victory", "steps", m.StepsTaken)
 			r.Victory = true
-			r.Message = fmt.Sprintf("Victory achieved in %d steps \n", currentMaze.StepsTaken)
+			r.Message = fmt.Sprintf("Victory achieved in %d steps \n", m.StepsTaken)
 		} else {
 			r.Error = true
-			r.Message = err.Error()
+			r.Message = e.Error()
 		}
 	}
 
 	r.Survey = s
 
-	c.JSON(http.StatusOK, r)
-}
-
-func initializeMaze() {
-	currentMaze = createMaze()
+	return http.StatusOK, r
 }
 
 // Print to the terminal the average steps to solution for the current session
 func printResults() {
-	fmt.Printf("Labyrinth solved %d times with an avg of %d steps\n", len(scores), mazelib.AvgScores(scores))
+	mu.Lock()
+	defer mu.Unlock()
+
+	logger.Info("results", "solved", len(scores), "avg_steps", mazelib.AvgScores(scores))
 }
 
 // Return a room from the maze
 func (m *Maze) GetRoom(x, y int) (*mazelib.Room, error) {
 	if x < 0 || y < 0 || x >= m.Width() || y >= m.Height() {
-		return &mazelib.Room{}, errors.New("room outside of maze boundaries")
+		return &mazelib.Room{}, mazelib.ErrOutOfBounds
 	}
 
 	return &m.rooms[y][x], nil
@@ -202,6 +469,7 @@ func (m *Maze) SetStartPoint(x, y int) error {
 	}
 
 	r.Start = true
+	m.start = mazelib.Coordinate{x, y}
 	m.icarus = mazelib.Coordinate{x, y}
 	return nil
 }
@@ -227,7 +495,6 @@ func (m *Maze) SetTreasure(x, y int) error {
 // Will return ErrVictory if Icarus is at the treasure.
 func (m *Maze) LookAround() (mazelib.Survey, error) {
 	if m.end.X == m.icarus.X && m.end.Y == m.icarus.Y {
-		fmt.Printf("Victory achieved in %d steps \n", m.StepsTaken)
 		return mazelib.Survey{}, mazelib.ErrVictory
 	}
 
@@ -244,6 +511,23 @@ func (m *Maze) Discover(x, y int) (mazelib.Survey, error) {
 	}
 }
 
+// Move moves Icarus one step in direction dir
+// Will not permit moving through walls or out of the maze
+func (m *Maze) Move(dir int) error {
+	switch dir {
+	case mazelib.W:
+		return m.MoveLeft()
+	case mazelib.E:
+		return m.MoveRight()
+	case mazelib.S:
+		return m.MoveDown()
+	case mazelib.N:
+		return m.MoveUp()
+	}
+
+	return nil
+}
+
 // Moves Icarus's position left one step
 // Will not permit moving through walls or out of the maze
 func (m *Maze) MoveLeft() error {
@@ -252,7 +536,7 @@ func (m *Maze) MoveLeft() error {
 		return e
 	}
 	if s.Left {
-		return errors.New("Can't walk through walls")
+		return mazelib.ErrWall
 	}
 
 	x, y := m.Icarus()
@@ -273,7 +557,7 @@ func (m *Maze) MoveRight() error {
 		return e
 	}
 	if s.Right {
-		return errors.New("Can't walk through walls")
+		return mazelib.ErrWall
 	}
 
 	x, y := m.Icarus()
@@ -294,7 +578,7 @@ func (m *Maze) MoveUp() error {
 		return e
 	}
 	if s.Top {
-		return errors.New("Can't walk through walls")
+		return mazelib.ErrWall
 	}
 
 	x, y := m.Icarus()
@@ -315,7 +599,7 @@ func (m *Maze) MoveDown() error {
 		return e
 	}
 	if s.Bottom {
-		return errors.New("Can't walk through walls")
+		return mazelib.ErrWall
 	}
 
 	x, y := m.Icarus()
@@ -331,9 +615,12 @@ func (m *Maze) MoveDown() error {
 // Creates a maze without any walls
 // Good starting point for additive algorithms
 func emptyMaze() *Maze {
+	return newMaze(viper.GetInt("width"), viper.GetInt("height"))
+}
+
+// newMaze creates a maze of the given size without any walls
+func newMaze(xSize, ySize int) *Maze {
 	z := Maze{}
-	ySize := viper.GetInt("height")
-	xSize := viper.GetInt("width")
 
 	z.rooms = make([][]mazelib.Room, ySize)
 	for y := 0; y < ySize; y++ {
@@ -362,28 +649,35 @@ func fullMaze() *Maze {
 	return z
 }
 
-type mazeBuilder func() *Maze
+// mazeBuilder creates a new maze, using r as the source of randomness
+type mazeBuilder func(r *rand.Rand) *Maze
+
+// builders holds all the maze builders by name
+var builders = map[string]mazeBuilder{
+	"empty":  createEmptyMaze,
+	"simple": createSimpleMaze,
+	"ring":   createRingMaze,
+	"btree":  createBtreeMaze,
+	"tree":   createTreeMaze,
+}
+
+// builderNames lists the names of the builders in the order they are
+// tried by pickBuilder
+var builderNames = []string{"empty", "simple", "ring", "btree", "tree"}
 
 var tracker struct {
 	scorecard   []int
 	lastBuilder int
-	builders    []mazeBuilder
 }
 
 // pickBuilder will keep tabs on the client's progress with each kind of
 // maze and randomly select one with a bias towards the kind of maze
 // that the client seems to have the most difficulty with.  All the ugly
-// details are kept inside the function.
-func pickBuilder() mazeBuilder {
+// details are kept inside the function. It returns the name of the
+// builder.
+func pickBuilder() string {
 	if tracker.scorecard == nil {
-		tracker.builders = []mazeBuilder{
-			createEmptyMaze,
-			createSimpleMaze,
-			createRingMaze,
-			createBtreeMaze,
-			createTreeMaze,
-		}
-		tracker.scorecard = make([]int, len(tracker.builders))
+		tracker.scorecard = make([]int, len(builderNames))
 	}
 
 	// fmt.Println(tracker.scorecard)
@@ -393,12 +687,12 @@ func pickBuilder() mazeBuilder {
 	}
 
 	lim := int(math.Sqrt(float64(viper.GetInt("times"))))
-	if s := len(tracker.builders) * len(tracker.builders); lim < s {
+	if s := len(builderNames) * len(builderNames); lim < s {
 		lim = s
 	}
 
 	if len(scores) < lim {
-		tracker.lastBuilder = (tracker.lastBuilder + 1) % len(tracker.builders)
+		tracker.lastBuilder = (tracker.lastBuilder + 1) % len(builderNames)
 	} else {
 		total := 0
 		for _, v := range tracker.scorecard {
@@ -415,39 +709,61 @@ func pickBuilder() mazeBuilder {
 		}
 	}
 
-	return tracker.builders[tracker.lastBuilder]
+	return builderNames[tracker.lastBuilder]
 }
 
-// createMaze creates a maze ready to be used by the client
-func createMaze() *Maze {
-	builder := pickBuilder()
+// createMaze creates a maze ready to be used by the client. If mazes
+// were loaded from files, it serves those instead.
+func createMaze() (*Maze, error) {
+	if len(mazeFiles) > 0 {
+		return pickMazeFile()
+	}
 
-	m := builder()
-	placeObjects(m)
-	return m
+	return buildMaze(pickBuilder(), rand.Int63())
+}
+
+// buildMaze creates a maze using the builder called name. The same
+// builder and seed always produce the same maze.
+func buildMaze(name string, seed int64) (*Maze, error) {
+	r := rand.New(rand.NewSource(seed))
+
+	m := builders[name](r)
+	m.builder = name
+	m.seed = seed
+	if err := placeObjects(m, r); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 // placeObjects places Icarus and the treasure in the maze, taking care
 // to not put Icarus and the treasure in the same location.
-func placeObjects(m *Maze) {
+func placeObjects(m *Maze, r *rand.Rand) error {
 	w, h := m.Width(), m.Height()
-	sx, sy := rand.Intn(w), rand.Intn(h)
-	m.SetStartPoint(sx, sy)
-	tx, ty := rand.Intn(w), rand.Intn(h)
-	// Don't put stuff on top of each other
-	if tx == sy && ty == sy {
-		if tx > 0 {
-			tx--
-		} else {
-			tx++
+	sx, sy := r.Intn(w), r.Intn(h)
+	if err := m.SetStartPoint(sx, sy); err != nil {
+		return err
+	}
+	tx, ty := r.Intn(w), r.Intn(h)
+	// Don't put stuff on top of each other, moving the treasure only
+	// along the sides of the maze that are more than a room long
+	if tx == sx && ty == sy {
+		if w > 1 {
+			if tx > 0 {
+				tx--
+			} else {
+				tx++
+			}
 		}
-		if ty > 0 {
-			ty--
-		} else {
-			ty++
+		if h > 1 {
+			if ty > 0 {
+				ty--
+			} else {
+				ty++
+			}
 		}
 	}
-	m.SetTreasure(tx, ty)
+	return m.SetTreasure(tx, ty)
 }
 
 // addExternalWalls adds the external walls to maze m, in order to make
@@ -473,7 +789,7 @@ func addExternalWalls(m *Maze) {
 
 // createEmptyMaze creates a maze without any walls inside. Wall-hughing
 // algorithms have a problem with this.
-func createEmptyMaze() *Maze {
+func createEmptyMaze(r *rand.Rand) *Maze {
 	m := emptyMaze()
 	addExternalWalls(m)
 
@@ -484,7 +800,7 @@ func createEmptyMaze() *Maze {
 // line. Depending on the relative location of Icarus and the treasure,
 // and the bias of the solving algorithm, this might cause it to take
 // ~2*N steps where N is the number of rooms in the maze.
-func createSimpleMaze() *Maze {
+func createSimpleMaze(r *rand.Rand) *Maze {
 	m := emptyMaze()
 	w, h := m.Width(), m.Height()
 
@@ -511,7 +827,7 @@ func createSimpleMaze() *Maze {
 // If the maze is large enough (100 rooms), the builder will sacrifice
 // some walls for larger groups of fully connected rooms, which cause
 // some implementations of backtracking algorithms to visit many rooms.
-func createRingMaze() *Maze {
+func createRingMaze(r *rand.Rand) *Maze {
 	m := emptyMaze()
 	w, h := m.Width(), m.Height()
 	step := 1
@@ -557,7 +873,7 @@ func createRingMaze() *Maze {
 // maze is perfect. There's always a large hallway to the west and the
 // north since most people have a tendency to prefer positive numbers,
 // and these directions require substracting.
-func createBtreeMaze() *Maze {
+func createBtreeMaze(r *rand.Rand) *Maze {
 	m := fullMaze()
 	w, h := m.Width(), m.Height()
 
@@ -581,7 +897,7 @@ func createBtreeMaze() *Maze {
 				// pick a random direction to go to out
 				// of the valid ones and make a passage
 				// to it
-				dir := dirs[rand.Intn(len(dirs))]
+				dir := dirs[r.Intn(len(dirs))]
 				mazelib.RmWall(m, x, y, dir)
 			}
 		}
@@ -597,13 +913,13 @@ func createBtreeMaze() *Maze {
 // maze is perfect. The maze is as random as it gets, and backtrackers
 // will probably excel here, and wall-hughers will always find a
 // solution.
-func createTreeMaze() *Maze {
+func createTreeMaze(r *rand.Rand) *Maze {
 	m := fullMaze()
 	w, h := m.Width(), m.Height()
 
 	// keep a list of the next rooms where we will be adding
 	// passages, add a random room to start with
-	c := []pos{{rand.Intn(w), rand.Intn(h)}}
+	c := []pos{{r.Intn(w), r.Intn(h)}}
 
 	// keep track of all the rooms we have already visited while
 	// building the maze
@@ -636,7 +952,7 @@ func createTreeMaze() *Maze {
 
 		if len(neighbors) > 0 {
 			// pick a random unvisited neighbor out of the valid ones
-			dir := neighbors[rand.Intn(len(neighbors))]
+			dir := neighbors[r.Intn(len(neighbors))]
 
 			// make a passage to that neighbor
 			mazelib.RmWall(m, t.x, t.y, dir)
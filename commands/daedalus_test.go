@@ -0,0 +1,102 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+)
+
+func TestPlaceObjectsApart(t *testing.T) {
+	// in a small maze the start and the treasure often land on the same
+	// room, also away from the diagonal
+	viper.Set("width", 3)
+	viper.Set("height", 2)
+	defer viper.Reset()
+
+	for seed := int64(1); seed <= 200; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		m := createEmptyMaze(r)
+		if err := placeObjects(m, r); err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if m.start == m.end {
+			t.Fatalf("seed %d: start and treasure both at %v", seed, m.start)
+		}
+	}
+}
+
+func TestBuildMazeNarrow(t *testing.T) {
+	defer viper.Reset()
+
+	for _, size := range [][2]int{{1, 2}, {2, 1}, {1, 5}, {5, 1}} {
+		viper.Set("width", size[0])
+		viper.Set("height", size[1])
+
+		for _, name := range builderNames {
+			for seed := int64(1); seed <= 50; seed++ {
+				m, err := buildMaze(name, seed)
+				if err != nil {
+					t.Fatalf("%dx%d %s maze %d: %v", size[0], size[1], name, seed, err)
+				}
+
+				// the start and the treasure are both there, in
+				// different rooms
+				if _, err := mazelib.NewBlueprint(m, mazelib.Info{}); err != nil {
+					t.Errorf("%dx%d %s maze %d: %v", size[0], size[1], name, seed, err)
+				}
+			}
+		}
+	}
+}
+
+func TestMovePathBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/moves", MovePath)
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{`null`, http.StatusBadRequest},
+		{`""`, http.StatusBadRequest},
+		{`[]`, http.StatusBadRequest},
+		{`{}`, http.StatusBadRequest},
+		{`"RX"`, http.StatusBadRequest},
+		{`["right", "sideways"]`, http.StatusBadRequest},
+		// there's no maze, but that's in the replies
+		{`"RRD"`, http.StatusOK},
+		{`["right", "down"]`, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("POST", "/moves", strings.NewReader(test.body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("%s: status %d, expecting %d", test.body, w.Code, test.status)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// generateCmd represents the generate command
+var generateCmd = &cobra.Command{
+	Use:   "generate <builder|all>",
+	Short: "Generate a maze without running a server",
+	Long: `Generate runs one of the maze builders used by daedalus, or all of
+  them, and writes the maze to the standard output or to a file.
+
+  The same builder, size and seed always produce the same maze. When
+  generating all the mazes, --output names a directory, where each maze
+  is written to a file named after its builder.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		if err := Generate(args[0]); err != nil {
+			logger.Error("can't generate maze", "builder", args[0], "err", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	generateCmd.Flags().Int64("seed", 0, "Seed for the builder (0 picks one at random)")
+	generateCmd.Flags().String("format", "ascii", "Output format: "+strings.Join(formatNames(), ", "))
+	generateCmd.Flags().StringP("output", "o", "", "File to write the maze to, instead of the standard output")
+
+	viper.BindPFlag("seed", generateCmd.Flags().Lookup("seed"))
+	viper.BindPFlag("format", generateCmd.Flags().Lookup("format"))
+	viper.BindPFlag("output", generateCmd.Flags().Lookup("output"))
+
+	RootCmd.AddCommand(generateCmd)
+}
+
+// mazeFormat writes a maze in some format
+type mazeFormat struct {
+	// ext is the extension for files in this format
+	ext string
+
+	// text is true if several mazes can be written one after the
+	// other in the same file
+	text bool
+
+	write func(w io.Writer, m *Maze) error
+}
+
+// mazeFormats holds the formats known to generate by name
+var mazeFormats = map[string]mazeFormat{
+	"ascii": {".txt", true, func(w io.Writer, m *Maze) error {
+		return mazelib.WriteMaze(w, m)
+	}},
+	"pretty": {".txt", true, func(w io.Writer, m *Maze) error {
+		return mazelib.WritePrettyMaze(w, m)
+	}},
+	"json": {".json", false, func(w io.Writer, m *Maze) error {
+		data, err := mazelib.Marshal(m, mazelib.Info{Generator: m.builder, Seed: m.seed})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}},
+	"binary": {".lmaz", false, func(w io.Writer, m *Maze) error {
+		return mazelib.WriteBinary(w, m)
+	}},
+	"svg": {".svg", false, func(w io.Writer, m *Maze) error {
+		return mazelib.WriteSVG(w, m, mazelib.RenderOptions{})
+	}},
+}
+
+// formatNames returns the names of the formats in mazeFormats, sorted
+func formatNames() []string {
+	names := make([]string, 0, len(mazeFormats))
+	for name := range mazeFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate builds a maze with the named builder, or one with each
+// builder if name is "all", and writes it as told by the format and
+// output options.
+func Generate(name string) error {
+	format, ok := mazeFormats[viper.GetString("format")]
+	if !ok {
+		return fmt.Errorf("unknown format %q, expecting one of %s", viper.GetString("format"), strings.Join(formatNames(), ", "))
+	}
+
+	names := []string{name}
+	if name == "all" {
+		names = builderNames
+	} else if _, ok := builders[name]; !ok {
+		return fmt.Errorf("unknown builder, expecting all or one of %s", strings.Join(builderNames, ", "))
+	}
+
+	if w, h := viper.GetInt("width"), viper.GetInt("height"); w < 1 || h < 1 || w*h < 2 {
+		return fmt.Errorf("invalid maze size %dx%d", w, h)
+	}
+
+	seed := viper.GetInt64("seed")
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+
+	output := viper.GetString("output")
+
+	if len(names) == 1 {
+		m, err := buildMaze(name, seed)
+		if err != nil {
+			return err
+		}
+		return writeMazeFile(output, format, m)
+	}
+
+	if output == "" && !format.text {
+		return fmt.Errorf("the %s format needs a directory given with --output", viper.GetString("format"))
+	}
+
+	if output != "" {
+		if err := os.MkdirAll(output, 0755); err != nil {
+			return err
+		}
+	}
+
+	for i, name := range names {
+		m, err := buildMaze(name, seed)
+		if err != nil {
+			return err
+		}
+
+		if output != "" {
+			if err := writeMazeFile(filepath.Join(output, name+format.ext), format, m); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(name)
+		if err := format.write(os.Stdout, m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeMazeFile writes maze m to the named file in the given format, or
+// to the standard output if name is empty
+func writeMazeFile(name string, format mazeFormat, m *Maze) error {
+	logger.Info("generated maze", "builder", m.builder, "seed", m.seed, "file", name)
+
+	if name == "" {
+		return format.write(os.Stdout, m)
+	}
+
+	return writeFile(name, func(w io.Writer) error {
+		return format.write(w, m)
+	})
+}
+
+// writeFile creates the named file and fills it using write
+func writeFile(name string, write func(w io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
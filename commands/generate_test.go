@@ -0,0 +1,81 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+)
+
+// roundTrips lists the formats in mazeFormats that can be read back,
+// and whether they keep the builder and the seed
+var roundTrips = []struct {
+	format string
+	info   bool
+}{
+	{"json", true},
+	{"binary", false},
+	{"ascii", false},
+	{"pretty", false},
+}
+
+func TestMazeFormatsRoundTrip(t *testing.T) {
+	defer viper.Reset()
+
+	for _, size := range [][2]int{{2, 2}, {7, 4}, {16, 9}} {
+		viper.Set("width", size[0])
+		viper.Set("height", size[1])
+
+		for _, name := range builderNames {
+			for seed := int64(1); seed <= 3; seed++ {
+				m, err := buildMaze(name, seed)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				for _, rt := range roundTrips {
+					var info mazelib.Info
+					if rt.info {
+						info = mazelib.Info{Generator: name, Seed: seed}
+					}
+
+					want, err := mazelib.NewBlueprint(m, info)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					var buf bytes.Buffer
+					if err := mazeFormats[rt.format].write(&buf, m); err != nil {
+						t.Fatalf("%s: %v", rt.format, err)
+					}
+
+					got, err := mazelib.ReadMaze(&buf)
+					if err != nil {
+						t.Fatalf("%s, %s maze %d: %v", rt.format, name, seed, err)
+					}
+
+					if !reflect.DeepEqual(got, want) {
+						t.Errorf("%s, %s maze %d: read %+v, expecting %+v", rt.format, name, seed, got, want)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"github.com/mem/labyrinth/daedaluspb"
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer implements the daedalus gRPC service using the same game
+// functions as the REST and websocket APIs.
+type grpcServer struct {
+	daedaluspb.UnimplementedDaedalusServer
+}
+
+// startGRPC starts serving the gRPC service, if a port for it was
+// configured. It returns the server, or nil if it's disabled.
+func startGRPC() (*grpc.Server, error) {
+	port := viper.GetInt("grpc-port")
+	if port == 0 {
+		return nil, nil
+	}
+
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("listening for gRPC", "addr", l.Addr().String())
+
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(grpcUnaryAuth),
+		grpc.StreamInterceptor(grpcStreamAuth),
+	)
+	daedaluspb.RegisterDaedalusServer(s, grpcServer{})
+	go s.Serve(l)
+
+	return s, nil
+}
+
+// stopGRPC stops s, waiting for the pending requests to finish until
+// ctx is done.
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// Awake implements daedaluspb.DaedalusServer
+func (grpcServer) Awake(ctx context.Context, req *daedaluspb.AwakeRequest) (*daedaluspb.Reply, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	m, err := newSession(currentMaze, grpcClientName(ctx))
+	currentMaze = m
+	switch {
+	case err == mazelib.ErrTooManySessions:
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case err != nil:
+		logger.Error("can't create maze", "err", err)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	code, r := startingPoint(currentMaze)
+	if code != http.StatusOK {
+		return nil, status.Error(codes.Internal, r.Message)
+	}
+
+	return toProtoReply(r), nil
+}
+
+// Move implements daedaluspb.DaedalusServer
+func (grpcServer) Move(ctx context.Context, req *daedaluspb.MoveRequest) (*daedaluspb.Reply, error) {
+	dir, ok := protoDirections[req.GetDirection()]
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "invalid direction")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	_, r := moveIcarus(currentMaze, dir)
+	return toProtoReply(r), nil
+}
+
+// Done implements daedaluspb.DaedalusServer
+func (grpcServer) Done(ctx context.Context, req *daedaluspb.DoneRequest) (*daedaluspb.Reply, error) {
+	shutdown()
+	return &daedaluspb.Reply{Message: "Goodbye"}, nil
+}
+
+// Play implements daedaluspb.DaedalusServer. Like a websocket
+// connection, each stream gets its own maze.
+func (grpcServer) Play(stream daedaluspb.Daedalus_PlayServer) error {
+	name := grpcClientName(stream.Context())
+
+	var m *Maze
+	defer closeSession(&m)
+
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		req := fromProtoRequest(in)
+		r, _ := playRequest(&m, req, name).(mazelib.Reply)
+
+		if err := stream.Send(toProtoReply(r)); err != nil {
+			return err
+		}
+
+		if req.Action == "done" {
+			return nil
+		}
+	}
+}
+
+// grpcClientName returns the name the client making the call with ctx
+// goes by: the name of its token, if it used one, or else the one given
+// in the x-icarus-name metadata
+func grpcClientName(ctx context.Context) string {
+	if t, ok := ctx.Value(identityKey{}).(apiToken); ok {
+		return t.Name
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if names := md.Get("x-icarus-name"); len(names) > 0 && names[0] != "" {
+		return names[0]
+	}
+	return anonymous
+}
+
+// protoDirections maps the directions of the gRPC service to the ones
+// in mazelib. DIRECTION_UNSPECIFIED and unknown values are left out.
+var protoDirections = map[daedaluspb.Direction]int{
+	daedaluspb.Direction_DIRECTION_UP:    mazelib.N,
+	daedaluspb.Direction_DIRECTION_DOWN:  mazelib.S,
+	daedaluspb.Direction_DIRECTION_RIGHT: mazelib.E,
+	daedaluspb.Direction_DIRECTION_LEFT:  mazelib.W,
+}
+
+// fromProtoRequest converts a request received over gRPC to the one
+// used by websocket connections
+func fromProtoRequest(in *daedaluspb.PlayRequest) mazelib.Request {
+	switch {
+	case in.GetAwake() != nil:
+		return mazelib.Request{Action: "awake"}
+	case in.GetMove() != nil:
+		// an unknown direction has no name, which playRequest
+		// rejects
+		dir := protoDirections[in.GetMove().GetDirection()]
+		return mazelib.Request{Action: "move", Direction: mazelib.DirectionName(dir)}
+	case in.GetDone() != nil:
+		return mazelib.Request{Action: "done"}
+	}
+
+	return mazelib.Request{}
+}
+
+// toProtoReply converts r to the reply sent over gRPC
+func toProtoReply(r mazelib.Reply) *daedaluspb.Reply {
+	return &daedaluspb.Reply{
+		Survey: &daedaluspb.Survey{
+			Top:    r.Survey.Top,
+			Right:  r.Survey.Right,
+			Bottom: r.Survey.Bottom,
+			Left:   r.Survey.Left,
+		},
+		Victory: r.Victory,
+		Message: r.Message,
+		Error:   r.Error,
+	}
+}
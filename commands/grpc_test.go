@@ -0,0 +1,87 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mem/labyrinth/daedaluspb"
+	"github.com/mem/labyrinth/mazelib"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestProtoDirections checks that every direction in the gRPC service
+// maps to the mazelib direction with the same name
+func TestProtoDirections(t *testing.T) {
+	seen := make(map[int]bool)
+
+	for name, v := range daedaluspb.Direction_value {
+		d := daedaluspb.Direction(v)
+		dir, ok := protoDirections[d]
+
+		if d == daedaluspb.Direction_DIRECTION_UNSPECIFIED {
+			if ok {
+				t.Errorf("%s maps to %d", name, dir)
+			}
+			continue
+		}
+
+		if !ok {
+			t.Errorf("%s isn't mapped", name)
+			continue
+		}
+		if want := "DIRECTION_" + strings.ToUpper(mazelib.DirectionName(dir)); name != want {
+			t.Errorf("%s maps to %s", name, want)
+		}
+		if seen[dir] {
+			t.Errorf("%s maps to %d, like another direction", name, dir)
+		}
+		seen[dir] = true
+	}
+
+	if len(seen) != 4 {
+		t.Errorf("%d directions mapped, expecting 4", len(seen))
+	}
+}
+
+func TestGRPCMoveDirection(t *testing.T) {
+	tests := []struct {
+		dir  daedaluspb.Direction
+		code codes.Code
+	}{
+		{daedaluspb.Direction_DIRECTION_UNSPECIFIED, codes.InvalidArgument},
+		{daedaluspb.Direction(99), codes.InvalidArgument},
+		{daedaluspb.Direction(-1), codes.InvalidArgument},
+		// there's no maze to move in, but that's in the reply
+		{daedaluspb.Direction_DIRECTION_LEFT, codes.OK},
+	}
+
+	for _, test := range tests {
+		_, err := grpcServer{}.Move(context.Background(), &daedaluspb.MoveRequest{Direction: test.dir})
+		if code := status.Code(err); code != test.code {
+			t.Errorf("%v: code %v, expecting %v", test.dir, code, test.code)
+		}
+
+		in := &daedaluspb.PlayRequest{Action: &daedaluspb.PlayRequest_Move{Move: &daedaluspb.MoveRequest{Direction: test.dir}}}
+		req := fromProtoRequest(in)
+		if _, err := mazelib.ParseDirection(req.Direction); (err == nil) != (test.code == codes.OK) {
+			t.Errorf("%v: streamed as %q", test.dir, req.Direction)
+		}
+	}
+}
@@ -17,13 +17,14 @@
 package commands
 
 import (
-	"encoding/json"
-	"errors"
-	"fmt"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"net/url"
+	"os"
+	"time"
 
+	"github.com/mem/labyrinth/client"
 	"github.com/mem/labyrinth/mazelib"
+	"github.com/mem/labyrinth/solver"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -49,96 +50,197 @@ func init() {
 	RootCmd.AddCommand(icarusCmd)
 }
 
-func RunIcarus() {
-	// Run the solver as many times as the user desires.
-	fmt.Println("Solving", viper.GetInt("times"), "times")
-	for x := 0; x < viper.GetInt("times"); x++ {
+// The client used to talk to daedalus
+var icarus *client.Client
+
+func init() {
+	icarusCmd.Flags().String("server", "", "URL of the daedalus server, use ws://host:port/ws for websockets (default is http://127.0.0.1:$PORT/)")
+	icarusCmd.Flags().Duration("timeout", 10*time.Second, "Timeout for requests to the server")
+
+	viper.BindPFlag("server", icarusCmd.Flags().Lookup("server"))
+	viper.BindPFlag("timeout", icarusCmd.Flags().Lookup("timeout"))
+}
 
-		solveMaze()
+// serverURL returns the URL of the daedalus server icarus should talk
+// to. If none was configured, the server is assumed to be running on
+// the local host.
+func serverURL() string {
+	if s := viper.GetString("server"); s != "" {
+		return s
 	}
+	return "http://127.0.0.1:" + viper.GetString("port") + "/"
+}
 
-	// Once we have solved the maze the required times, tell daedalus we are done
-	makeRequest("http://127.0.0.1:" + viper.GetString("port") + "/done")
+// RunIcarus solves mazes using the daedalus server configured by the
+// user.
+func RunIcarus() {
+	runRemoteIcarus(serverURL())
 }
 
-// Make a call to the laybrinth server (daedalus) that icarus is ready to wake up
-func awake() mazelib.Survey {
-	contents, err := makeRequest("http://127.0.0.1:" + viper.GetString("port") + "/awake")
+// runRemoteIcarus solves mazes using the daedalus server at URL server,
+// once it's ready to accept requests.
+func runRemoteIcarus(server string) {
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("wait"))
+	t, err := dial(ctx, server)
+	cancel()
 	if err != nil {
-		fmt.Println(err)
+		logger.Error("daedalus is not ready", "server", server, "err", err)
+		os.Exit(-1)
 	}
-	r := ToReply(contents)
-	return r.Survey
+
+	runSolver(client.NewWithTransport(t))
 }
 
-// Make a call to the laybrinth server (daedalus)
-// to move Icarus a given direction
-// Will be used heavily by solveMaze
-func Move(direction string) (mazelib.Survey, error) {
-	if direction == "left" || direction == "right" || direction == "up" || direction == "down" {
+// dial returns a transport for the server at URL server, which uses a
+// websocket connection if the URL has the ws or wss scheme, and HTTP
+// requests otherwise. It waits until the server is ready or ctx is
+// done.
+func dial(ctx context.Context, server string) (client.Transport, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	// the server takes the name of the client from the query, which
+	// both transports keep in every request
+	if name := viper.GetString("name"); name != "" {
+		q := u.Query()
+		q.Set("name", name)
+		u.RawQuery = q.Encode()
+		server = u.String()
+	}
+
+	timeout := viper.GetDuration("timeout")
+
+	token := viper.GetString("token")
 
-		contents, err := makeRequest("http://127.0.0.1:" + viper.GetString("port") + "/move/" + direction)
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		t, err := client.NewHTTPTransport(server, timeout)
 		if err != nil {
-			return mazelib.Survey{}, err
+			return nil, err
+		}
+		t.Token = token
+		return t, t.Wait(ctx)
+	}
+
+	for {
+		t, err := client.DialWebSocket(ctx, server, token, timeout)
+		if err == nil {
+			return t, nil
 		}
 
-		rep := ToReply(contents)
-		switch {
-		case rep.Victory == true:
-			fmt.Println(rep.Message)
-			// os.Exit(1)
-			return rep.Survey, mazelib.ErrVictory
-		case rep.Error == true:
-			return rep.Survey, errors.New(rep.Message)
-		default:
-			return rep.Survey, nil
+		// the server is up, but it won't let us in
+		if _, ok := err.(*client.ServerError); ok {
+			return nil, err
 		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// RunLocalIcarus solves mazes created by daedalus running in the same
+// process. Like the server, it serves the mazes given with --maze-file,
+// saves the scores in --scores-file and records the sessions in
+// --record-dir.
+func RunLocalIcarus() {
+	if err := loadMazeFiles(); err != nil {
+		logger.Error("can't load mazes", "err", err)
+		os.Exit(-1)
+	}
+
+	if err := openHistory(); err != nil {
+		logger.Error("can't open scores file", "err", err)
+		os.Exit(-1)
 	}
+	defer closeHistory()
+	defer closeRecordings()
 
-	return mazelib.Survey{}, errors.New("invalid direction")
+	runSolver(client.NewWithTransport(localTransport{}))
 }
 
-// UndoMove tells the server to move Icarus in the opposite direction of
-// `direction` to support backing off.
-func UndoMove(direction string) (mazelib.Survey, error) {
-	switch direction {
-	case "left":
-		direction = "right"
-	case "right":
-		direction = "left"
-	case "up":
-		direction = "down"
-	case "down":
-		direction = "up"
+// runSolver solves as many mazes as required by the user, using c to
+// talk to the server.
+func runSolver(c *client.Client) {
+	icarus = c
+	icarus.MaxSteps = viper.GetInt("max-steps")
+
+	ctx := context.Background()
+
+	// Run the solver as many times as the user desires.
+	logger.Info("solving", "times", viper.GetInt("times"), "solver", viper.GetString("solver"))
+	for x := 0; x < viper.GetInt("times"); x++ {
+		if err := solveMaze(ctx, icarus); err != nil {
+			logger.Error("can't solve maze", "maze", x+1, "err", err)
+		}
 	}
-	return Move(direction)
+
+	// Once we have solved the maze the required times, tell daedalus we are done
+	icarus.Done(ctx)
 }
 
-// utility function to wrap making requests to the daedalus server
-func makeRequest(url string) ([]byte, error) {
-	response, err := http.Get(url)
+// solveMaze asks daedalus for a new maze using c and runs the configured
+// solver on it
+func solveMaze(ctx context.Context, c *client.Client) error {
+	s, err := c.Awake(ctx) // Need to start with waking up to initialize a new maze
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+
+	m := &clientMover{ctx: ctx, c: c}
+
+	var solved bool
+	switch viper.GetString("solver") {
+	case "explorer":
+		solved = solver.NewExplorer(m).Solve(s)
+	default:
+		solved = RecursiveSolve(m, s)
+	}
+
+	switch {
+	case solved:
+		logger.Info("victory", "steps", c.Steps())
+	case m.err != nil:
+		return m.err
+	default:
+		logger.Warn("gave up", "steps", c.Steps())
 	}
-	return contents, nil
+
+	return nil
 }
 
-// Handling a JSON response and unmarshalling it into a reply struct
-func ToReply(in []byte) mazelib.Reply {
-	res := &mazelib.Reply{}
-	json.Unmarshal(in, &res)
-	return *res
+// clientMover moves Icarus using a client. It remembers the last
+// unexpected error, so that the caller can find out why a solver gave
+// up.
+type clientMover struct {
+	ctx context.Context
+	c   *client.Client
+	err error
 }
 
-func solveMaze() {
-	s := awake() // Need to start with waking up to initialize a new maze
+func (m *clientMover) Move(dir int) (mazelib.Survey, error) {
+	s, err := m.c.Move(m.ctx, dir)
+	m.check(err)
+	return s, err
+}
 
-	RecursiveSolve(s)
+func (m *clientMover) MovePath(path []int) ([]mazelib.Survey, error) {
+	s, err := m.c.MovePath(m.ctx, path)
+	m.check(err)
+	return s, err
+}
+
+// check remembers err if it's not one of the errors a solver is
+// expected to deal with
+func (m *clientMover) check(err error) {
+	switch err {
+	case nil, client.ErrVictory, client.ErrWall, client.ErrBudgetExhausted:
+	default:
+		m.err = err
+	}
 }
 
 type pos struct {
@@ -148,18 +250,21 @@ type pos struct {
 // RecursiveSolver solves mazes recursively, implementing a backtracking
 // strategy
 type RecursiveSolver struct {
+	m       solver.Mover
 	visited map[pos]bool
 }
 
 // RecursiveSolve creates a RecursiveSolver and solves the maze,
 // returning true if a solution was found
-func RecursiveSolve(s mazelib.Survey) bool {
-	return NewRecursiveSolver().solve(s, pos{})
+func RecursiveSolve(m solver.Mover, s mazelib.Survey) bool {
+	return NewRecursiveSolver(m).solve(s, pos{})
 }
 
-// NewRecursiveSolver creates a new recursive solver
-func NewRecursiveSolver() *RecursiveSolver {
+// NewRecursiveSolver creates a new recursive solver that moves Icarus
+// using m
+func NewRecursiveSolver(m solver.Mover) *RecursiveSolver {
 	return &RecursiveSolver{
+		m:       m,
 		visited: make(map[pos]bool),
 	}
 }
@@ -169,58 +274,36 @@ func NewRecursiveSolver() *RecursiveSolver {
 // more options are available. It has a right and bottom bias.
 func (solver *RecursiveSolver) solve(s mazelib.Survey, p pos) bool {
 	solver.visited[p] = true
-	if s.Right == false && solver.right(p) {
+	if s.Right == false && solver.visit(mazelib.E, p) {
 		return true
 	}
-	if s.Bottom == false && solver.down(p) {
+	if s.Bottom == false && solver.visit(mazelib.S, p) {
 		return true
 	}
-	if s.Left == false && solver.left(p) {
+	if s.Left == false && solver.visit(mazelib.W, p) {
 		return true
 	}
-	if s.Top == false && solver.up(p) {
+	if s.Top == false && solver.visit(mazelib.N, p) {
 		return true
 	}
 	return false
 }
 
-// right moves Icarus east
-func (solver *RecursiveSolver) right(p pos) bool {
-	x, y := mazelib.Shift(p.x, p.y, mazelib.E)
-	return solver.visit("right", pos{x, y})
-}
-
-// down moves Icarus south
-func (solver *RecursiveSolver) down(p pos) bool {
-	x, y := mazelib.Shift(p.x, p.y, mazelib.S)
-	return solver.visit("down", pos{x, y})
-}
-
-// left moves Icarus west
-func (solver *RecursiveSolver) left(p pos) bool {
-	x, y := mazelib.Shift(p.x, p.y, mazelib.W)
-	return solver.visit("left", pos{x, y})
-}
-
-// up moves Icarus north
-func (solver *RecursiveSolver) up(p pos) bool {
-	x, y := mazelib.Shift(p.x, p.y, mazelib.N)
-	return solver.visit("up", pos{x, y})
-}
-
 // visit is the common method all the movements use to tell the server
 // the direction we want to move, call solve on the new position, and
 // back off if that move didn't find a solution.
-func (solver *RecursiveSolver) visit(dir string, p pos) bool {
+func (solver *RecursiveSolver) visit(dir int, p pos) bool {
+	x, y := mazelib.Shift(p.x, p.y, dir)
+	p = pos{x, y}
 	if solver.visited[p] {
 		return false
 	}
-	switch t, err := Move(dir); err {
+	switch t, err := solver.m.Move(dir); err {
 	case nil:
 		if solver.solve(t, p) {
 			return true
 		}
-		UndoMove(dir)
+		solver.m.Move(mazelib.Reverse(dir))
 	case mazelib.ErrVictory:
 		return true
 	}
@@ -21,6 +21,7 @@ package commands
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"time"
 
@@ -37,7 +38,8 @@ var CfgFile string
 // Defining the daedalus command.
 // This will be called as 'laybrinth'
 // The default behavior will be to run both a server (daedalus)
-// and a client (icarus) and connect them to each other.
+// and a client (icarus) and connect them to each other, within the
+// same process unless --http is given.
 var RootCmd = &cobra.Command{
 	Use:   "labyrinth",
 	Short: "a labyrinth generator and solver",
@@ -53,13 +55,35 @@ if there is a wall or not to the top, right, bottom and left. He takes
 one step and then can discover if his new cell has walls on each of
 the four sides.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		go RunServer()
-
-		// give server time to start before sending a request.
-		// There's a better way to do this, but I'm lazy and this is just for fun.
-		time.Sleep(1 * time.Second)
-
-		RunIcarus()
+		if !viper.GetBool("http") {
+			RunLocalIcarus()
+			return
+		}
+
+		ready := make(chan net.Addr, 1)
+		errc := make(chan error, 1)
+		go func() {
+			errc <- RunServer(ready)
+		}()
+
+		// wait for the server to start before sending a request.
+		select {
+		case addr := <-ready:
+			port := addr.(*net.TCPAddr).Port
+			runRemoteIcarus(fmt.Sprintf("http://127.0.0.1:%d/", port))
+		case err := <-errc:
+			logger.Error("daedalus failed", "err", err)
+			os.Exit(-1)
+		case <-time.After(viper.GetDuration("wait")):
+			logger.Error("timed out waiting for daedalus to start")
+			os.Exit(-1)
+		}
+
+		// icarus is done, wait for the server to shut down
+		if err := <-errc; err != nil {
+			logger.Error("daedalus failed", "err", err)
+			os.Exit(-1)
+		}
 	},
 }
 
@@ -68,13 +92,25 @@ func init() {
 
 	// Setting flags here so they can be used by both the root behavior as well as
 	// by the indidual behaviors of icarus and daedalus
+	RootCmd.Flags().Bool("http", false, "Connect icarus and daedalus over HTTP instead of in-process")
+	viper.BindPFlag("http", RootCmd.Flags().Lookup("http"))
+
 	RootCmd.PersistentFlags().StringVar(&CfgFile, "config", "", "config file (default is $CWD/config.yaml)")
-	RootCmd.PersistentFlags().IntP("port", "p", 8013, "Port run on")
+	RootCmd.PersistentFlags().IntP("port", "p", 8013, "Port run on (0 picks any available port)")
 	RootCmd.PersistentFlags().IntP("width", "x", 15, "width of the laybrinth")
 	RootCmd.PersistentFlags().IntP("height", "y", 10, "height of the laybrinth") // 'h' is used for help already
 	RootCmd.PersistentFlags().IntP("times", "t", 1, "times to solve the laybrinth")
 	RootCmd.PersistentFlags().IntP("max-steps", "m", 500, "Maximum steps before giving up")
 	RootCmd.PersistentFlags().BoolP("pretty", "r", false, "Pretty print mazes")
+	RootCmd.PersistentFlags().Duration("wait", 5*time.Second, "How long to wait for the server to be ready")
+	RootCmd.PersistentFlags().StringP("solver", "s", "recursive", "Solver to use (recursive, explorer)")
+	RootCmd.PersistentFlags().String("name", "", "Name Icarus goes by in the leaderboard")
+	RootCmd.PersistentFlags().String("token", "", "Token Icarus uses to authenticate with daedalus")
+	RootCmd.PersistentFlags().String("scores-file", "", "File where daedalus saves the scores for the leaderboard")
+	RootCmd.PersistentFlags().String("record-dir", "", "Directory where daedalus writes a replay log for each session")
+	RootCmd.PersistentFlags().String("maze-file", "", "Maze file, or directory of maze files served in turn, for daedalus to use instead of generating mazes")
+	RootCmd.PersistentFlags().String("log-level", "info", "Log level (debug, info, warn, error); mazes are logged at debug")
+	RootCmd.PersistentFlags().String("log-format", "text", "Log format (text, json)")
 
 	// Bind viper to these flags so viper can read flag values along with config, env, etc.
 	viper.BindPFlag("width", RootCmd.PersistentFlags().Lookup("width"))
@@ -83,6 +119,15 @@ func init() {
 	viper.BindPFlag("times", RootCmd.PersistentFlags().Lookup("times"))
 	viper.BindPFlag("max-steps", RootCmd.PersistentFlags().Lookup("max-steps"))
 	viper.BindPFlag("pretty", RootCmd.PersistentFlags().Lookup("pretty"))
+	viper.BindPFlag("wait", RootCmd.PersistentFlags().Lookup("wait"))
+	viper.BindPFlag("solver", RootCmd.PersistentFlags().Lookup("solver"))
+	viper.BindPFlag("name", RootCmd.PersistentFlags().Lookup("name"))
+	viper.BindPFlag("token", RootCmd.PersistentFlags().Lookup("token"))
+	viper.BindPFlag("scores-file", RootCmd.PersistentFlags().Lookup("scores-file"))
+	viper.BindPFlag("record-dir", RootCmd.PersistentFlags().Lookup("record-dir"))
+	viper.BindPFlag("maze-file", RootCmd.PersistentFlags().Lookup("maze-file"))
+	viper.BindPFlag("log-level", RootCmd.PersistentFlags().Lookup("log-level"))
+	viper.BindPFlag("log-format", RootCmd.PersistentFlags().Lookup("log-format"))
 }
 
 // Read in config file and ENV variables if set.
@@ -98,8 +143,15 @@ func initConfig() {
 	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config.yaml file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+	found := viper.ReadInConfig() == nil
+
+	if err := setupLogger(); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+
+	if found {
+		logger.Info("using config file", "file", viper.ConfigFileUsed())
 	}
 }
 
@@ -0,0 +1,296 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// leaderboardCmd represents the leaderboard command
+var leaderboardCmd = &cobra.Command{
+	Use:   "leaderboard",
+	Short: "Show how well each client has done",
+	Long: `Leaderboard reads the scores saved by daedalus with --scores-file and
+  shows, for each client, builder and maze size, how many mazes were
+  solved and how efficiently, compared to the shortest path to the
+  treasure.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		file := viper.GetString("scores-file")
+		if file == "" {
+			logger.Error("no scores file given, use --scores-file")
+			os.Exit(-1)
+		}
+
+		results, err := readResults(file)
+		if err != nil {
+			logger.Error("can't read scores", "file", file, "err", err)
+			os.Exit(-1)
+		}
+
+		printStandings(leaderboard(results, viper.GetString("client")))
+	},
+}
+
+func init() {
+	leaderboardCmd.Flags().String("client", "", "Only show this client")
+	viper.BindPFlag("client", leaderboardCmd.Flags().Lookup("client"))
+
+	RootCmd.AddCommand(leaderboardCmd)
+}
+
+// anonymous is the name given to clients that don't say who they are
+const anonymous = "anonymous"
+
+// result records a maze solved by a client
+type result struct {
+	Client  string    `json:"client"`
+	Builder string    `json:"builder"`
+	Width   int       `json:"width"`
+	Height  int       `json:"height"`
+	Seed    int64     `json:"seed"`
+	Steps   int       `json:"steps"`
+	Optimal int       `json:"optimal"`
+	Time    time.Time `json:"time"`
+}
+
+// efficiency returns how close to the shortest path the client got, 1
+// being a perfect score
+func (r result) efficiency() float64 {
+	if r.Steps == 0 {
+		return 1
+	}
+	return float64(r.Optimal) / float64(r.Steps)
+}
+
+// standing summarizes the results of a client for one builder and maze
+// size
+type standing struct {
+	Client         string  `json:"client"`
+	Builder        string  `json:"builder"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	Solved         int     `json:"solved"`
+	BestSteps      int     `json:"best_steps"`
+	AvgSteps       float64 `json:"avg_steps"`
+	BestEfficiency float64 `json:"best_efficiency"`
+	AvgEfficiency  float64 `json:"avg_efficiency"`
+}
+
+// Like the scores, the history of results and the file it's saved to
+// are protected by mu.
+var (
+	history     []result
+	historyFile *os.File
+)
+
+// openHistory loads the results saved in the scores file, if there's
+// one, and opens it so that new results are added to it.
+func openHistory() error {
+	file := viper.GetString("scores-file")
+	if file == "" {
+		return nil
+	}
+
+	results, err := readResults(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	history = results
+	historyFile = f
+
+	return nil
+}
+
+// closeHistory closes the scores file
+func closeHistory() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if historyFile != nil {
+		historyFile.Close()
+		historyFile = nil
+	}
+}
+
+// recordResult adds the result of the client that just solved maze m
+// to the history, and saves it to the scores file. mu must be held.
+func recordResult(m *Maze) {
+	r := result{
+		Client:  m.client,
+		Builder: m.builder,
+		Width:   m.Width(),
+		Height:  m.Height(),
+		Seed:    m.seed,
+		Steps:   m.StepsTaken,
+		Optimal: len(mazelib.ShortestPath(m, m.start, m.end)),
+		Time:    time.Now().UTC(),
+	}
+
+	history = append(history, r)
+
+	if historyFile == nil {
+		return
+	}
+
+	b, err := json.Marshal(r)
+	if err == nil {
+		_, err = historyFile.Write(append(b, '\n'))
+	}
+	if err != nil {
+		sessionLogger(m).Error("can't save score", "err", err)
+	}
+}
+
+// readResults reads the results saved in file, one JSON object per
+// line
+func readResults(file string) ([]result, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var results []result
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var r result
+		if err := json.Unmarshal(s.Bytes(), &r); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		results = append(results, r)
+	}
+
+	return results, s.Err()
+}
+
+// leaderboard summarizes results by client, builder and maze size,
+// best average efficiency first. If client isn't empty, only that
+// client's results are included.
+func leaderboard(results []result, client string) []standing {
+	type key struct {
+		client, builder string
+		width, height   int
+	}
+
+	byKey := make(map[key]*standing)
+	var standings []*standing
+
+	for _, r := range results {
+		if client != "" && r.Client != client {
+			continue
+		}
+
+		k := key{r.Client, r.Builder, r.Width, r.Height}
+		s, ok := byKey[k]
+		if !ok {
+			s = &standing{Client: r.Client, Builder: r.Builder, Width: r.Width, Height: r.Height}
+			byKey[k] = s
+			standings = append(standings, s)
+		}
+
+		if s.Solved == 0 || r.Steps < s.BestSteps {
+			s.BestSteps = r.Steps
+		}
+		if e := r.efficiency(); e > s.BestEfficiency {
+			s.BestEfficiency = e
+		}
+		s.Solved++
+		// keep running totals, they are turned into averages below
+		s.AvgSteps += float64(r.Steps)
+		s.AvgEfficiency += r.efficiency()
+	}
+
+	out := make([]standing, 0, len(standings))
+	for _, s := range standings {
+		s.AvgSteps /= float64(s.Solved)
+		s.AvgEfficiency /= float64(s.Solved)
+		out = append(out, *s)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].AvgEfficiency != out[j].AvgEfficiency {
+			return out[i].AvgEfficiency > out[j].AvgEfficiency
+		}
+		return out[i].Client < out[j].Client
+	})
+
+	return out
+}
+
+// printStandings prints the leaderboard as a table
+func printStandings(standings []standing) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT\tBUILDER\tSIZE\tSOLVED\tBEST STEPS\tAVG STEPS\tBEST EFF\tAVG EFF")
+	for _, s := range standings {
+		fmt.Fprintf(w, "%s\t%s\t%dx%d\t%d\t%d\t%.1f\t%.2f\t%.2f\n",
+			s.Client, s.Builder, s.Width, s.Height, s.Solved,
+			s.BestSteps, s.AvgSteps, s.BestEfficiency, s.AvgEfficiency)
+	}
+	w.Flush()
+}
+
+// clientName returns the name the client making request c goes by:
+// the name of its token, if it used one, or else the one given in the
+// X-Icarus-Name header or the name query parameter
+func clientName(c *gin.Context) string {
+	if t, ok := identity(c); ok {
+		return t.Name
+	}
+	if name := c.GetHeader("X-Icarus-Name"); name != "" {
+		return name
+	}
+	if name := c.Query("name"); name != "" {
+		return name
+	}
+	return anonymous
+}
+
+// The API response to the /leaderboard address
+//
+// Leaderboard summarizes the results of all the clients, or just the
+// one given by the client query parameter.
+func Leaderboard(c *gin.Context) {
+	mu.Lock()
+	standings := leaderboard(history, c.Query("client"))
+	mu.Unlock()
+
+	c.JSON(http.StatusOK, standings)
+}
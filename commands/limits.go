@@ -0,0 +1,120 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"math"
+	"time"
+
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+)
+
+// limiters holds the move rate limiter of each client. Like the
+// sessions, it's protected by mu.
+var limiters = make(map[string]*rate.Limiter)
+
+// clock returns the time used to limit moves and to tell when sessions
+// were last played
+var clock = time.Now
+
+// allowMove returns true if the named client can move now, according
+// to the move-rate option. Each client can move up to that many times
+// per second, in bursts of up to a second's worth of moves. mu must be
+// held.
+func allowMove(client string) bool {
+	r := viper.GetFloat64("move-rate")
+	if r <= 0 {
+		return true
+	}
+
+	l, ok := limiters[client]
+	if !ok {
+		l = rate.NewLimiter(rate.Limit(r), int(math.Ceil(r)))
+		limiters[client] = l
+	}
+
+	return l.AllowN(clock(), 1)
+}
+
+// checkSessions returns mazelib.ErrTooManySessions if the named client
+// is already playing as many mazes as allowed by the max-sessions
+// option. mu must be held.
+func checkSessions(client string) error {
+	max := viper.GetInt("max-sessions")
+	if max <= 0 {
+		return nil
+	}
+
+	n := 0
+	for _, m := range sessions {
+		if m.client == client {
+			n++
+		}
+	}
+
+	if n >= max {
+		return mazelib.ErrTooManySessions
+	}
+
+	return nil
+}
+
+// active returns true if maze m is still being played, that is, its
+// session hasn't ended or expired. mu must be held.
+func active(m *Maze) bool {
+	return sessions[m.id] == m
+}
+
+// expireSessions ends the sessions that haven't been played for longer
+// than the session-idle option, and forgets the rate limiters that
+// aren't limiting anybody.
+func expireSessions(now time.Time) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if idle := viper.GetDuration("session-idle"); idle > 0 {
+		for _, m := range sessions {
+			if now.Sub(m.lastActive) < idle {
+				continue
+			}
+			sessionLogger(m).Info("session expired", "steps", m.StepsTaken)
+			endSession(m)
+		}
+	}
+
+	for client, l := range limiters {
+		if l.TokensAt(now) >= float64(l.Burst()) {
+			delete(limiters, client)
+		}
+	}
+}
+
+// expireIdle runs expireSessions every second until done is closed
+func expireIdle(done <-chan struct{}) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+
+	for {
+		select {
+		case now := <-t.C:
+			expireSessions(now)
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"golang.org/x/time/rate"
+)
+
+// fakeClock makes the limits use the time in now, starting with a
+// clean slate. Mazes are one room wide, so Icarus can always walk
+// into the wall on his right without finding the treasure.
+func fakeClock() (now *time.Time, reset func()) {
+	t := time.Date(2015, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock = func() time.Time { return t }
+
+	viper.Set("width", 1)
+	viper.Set("height", 2)
+
+	return &t, func() {
+		clock = time.Now
+		currentMaze = nil
+		sessions = make(map[int]*Maze)
+		limiters = make(map[string]*rate.Limiter)
+		viper.Reset()
+	}
+}
+
+// request makes a request as the named client and returns the status
+func request(r *gin.Engine, url, name string) int {
+	req := httptest.NewRequest("GET", url, nil)
+	req.Header.Set("X-Icarus-Name", name)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func playRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	play := r.Group("/", authenticate)
+	play.GET("/awake", GetStartingPoint)
+	play.GET("/move/:direction", MoveDirection)
+
+	return r
+}
+
+func TestMoveRate(t *testing.T) {
+	now, reset := fakeClock()
+	defer reset()
+	viper.Set("move-rate", 2)
+
+	r := playRouter()
+	if code := request(r, "/awake", "alice"); code != http.StatusOK {
+		t.Fatalf("awake: status %d", code)
+	}
+
+	tests := []struct {
+		wait   time.Duration
+		status int
+	}{
+		// a burst of up to a second's worth of moves
+		{0, http.StatusConflict},
+		{0, http.StatusConflict},
+		{0, http.StatusTooManyRequests},
+		{100 * time.Millisecond, http.StatusTooManyRequests},
+		// one more move every half a second
+		{400 * time.Millisecond, http.StatusConflict},
+		{0, http.StatusTooManyRequests},
+		{2 * time.Second, http.StatusConflict},
+		{0, http.StatusConflict},
+		{0, http.StatusTooManyRequests},
+	}
+
+	for i, test := range tests {
+		*now = now.Add(test.wait)
+		if code := request(r, "/move/right", "alice"); code != test.status {
+			t.Errorf("move %d: status %d, expecting %d", i+1, code, test.status)
+		}
+	}
+}
+
+func TestMaxSessions(t *testing.T) {
+	tests := []struct {
+		max    int
+		others []string
+		status int
+	}{
+		{0, []string{"alice", "alice"}, http.StatusOK},
+		{1, nil, http.StatusOK},
+		{1, []string{"alice"}, http.StatusTooManyRequests},
+		{1, []string{"bob"}, http.StatusOK},
+		{2, []string{"alice"}, http.StatusOK},
+		{2, []string{"alice", "bob", "alice"}, http.StatusTooManyRequests},
+	}
+
+	for _, test := range tests {
+		_, reset := fakeClock()
+		viper.Set("max-sessions", test.max)
+
+		// sessions played over websockets, which /awake doesn't end
+		for i, name := range test.others {
+			sessions[100+i] = &Maze{id: 100 + i, client: name}
+		}
+
+		if code := request(playRouter(), "/awake", "alice"); code != test.status {
+			t.Errorf("max %d with sessions for %v: status %d, expecting %d", test.max, test.others, code, test.status)
+		}
+
+		reset()
+	}
+}
+
+func TestExpireSessions(t *testing.T) {
+	now, reset := fakeClock()
+	defer reset()
+	viper.Set("session-idle", 10*time.Minute)
+	viper.Set("move-rate", 1)
+
+	r := playRouter()
+	if code := request(r, "/awake", "alice"); code != http.StatusOK {
+		t.Fatalf("awake: status %d", code)
+	}
+	start := *now
+
+	// moving keeps the session alive
+	*now = start.Add(5 * time.Minute)
+	if code := request(r, "/move/right", "alice"); code != http.StatusConflict {
+		t.Fatalf("move: status %d", code)
+	}
+	expireSessions(start.Add(14 * time.Minute))
+	if len(sessions) != 1 {
+		t.Fatalf("%d sessions after 9 idle minutes, expecting 1", len(sessions))
+	}
+
+	expireSessions(start.Add(16 * time.Minute))
+	if len(sessions) != 0 {
+		t.Errorf("%d sessions after 11 idle minutes, expecting none", len(sessions))
+	}
+	if len(limiters) != 0 {
+		t.Errorf("%d rate limiters left, expecting none", len(limiters))
+	}
+
+	*now = start.Add(16 * time.Minute)
+	if code := request(r, "/move/right", "alice"); code != http.StatusGone {
+		t.Errorf("move after expiring: status %d, expecting %d", code, http.StatusGone)
+	}
+}
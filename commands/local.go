@@ -0,0 +1,72 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"context"
+
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+)
+
+// localTransport lets a client talk to daedalus within the same
+// process, skipping HTTP altogether. It uses the same maze and scores
+// as the server does, so it has the same limitations with regards to
+// concurrent use.
+type localTransport struct{}
+
+// Awake implements client.Transport
+func (localTransport) Awake(ctx context.Context) (mazelib.Reply, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	name := viper.GetString("name")
+	if name == "" {
+		name = anonymous
+	}
+
+	m, err := newSession(currentMaze, name)
+	currentMaze = m
+	if err != nil {
+		return mazelib.Reply{Error: true, Message: err.Error()}, nil
+	}
+
+	_, r := startingPoint(currentMaze)
+	return r, nil
+}
+
+// Move implements client.Transport
+func (localTransport) Move(ctx context.Context, dir int) (mazelib.Reply, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	_, r := moveIcarus(currentMaze, dir)
+	return r, nil
+}
+
+// MovePath implements client.Transport
+func (localTransport) MovePath(ctx context.Context, path []int) (mazelib.BatchReply, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return moveIcarusPath(currentMaze, path), nil
+}
+
+// Done implements client.Transport
+func (localTransport) Done(ctx context.Context) error {
+	printResults()
+	return nil
+}
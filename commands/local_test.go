@@ -0,0 +1,58 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLocalRecording(t *testing.T) {
+	dir := t.TempDir()
+	viper.Set("record-dir", dir)
+	viper.Set("width", 5)
+	viper.Set("height", 4)
+	viper.Set("times", 1)
+	viper.Set("max-steps", 1000)
+	defer viper.Reset()
+
+	RunLocalIcarus()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("%d replay logs, expecting 1", len(files))
+	}
+
+	events, err := readRecording(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) < 2 {
+		t.Fatalf("%d events recorded, expecting the maze and some moves", len(events))
+	}
+
+	if e := events[0]; e.Type != "maze" || e.Maze == nil {
+		t.Errorf("first event is %+v, expecting the maze", e)
+	}
+	if e := events[len(events)-1]; e.Type != "move" || !e.Victory {
+		t.Errorf("last event is %+v, expecting the winning move", e)
+	}
+}
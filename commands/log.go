@@ -0,0 +1,96 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+)
+
+// logger is used by both daedalus and icarus. It's set up according to
+// the log-level and log-format options before any command runs.
+var logger = slog.Default()
+
+// setupLogger creates the logger as configured by the user
+func setupLogger() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(viper.GetString("log-level"))); err != nil {
+		return fmt.Errorf("invalid log level %q", viper.GetString("log-level"))
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch viper.GetString("log-format") {
+	case "text":
+		logger = slog.New(slog.NewTextHandler(os.Stderr, opts))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	default:
+		return fmt.Errorf("invalid log format %q", viper.GetString("log-format"))
+	}
+
+	return nil
+}
+
+// sessionLogger returns a logger that adds the session, the client and
+// the builder of maze m to every message, and the file it came from if
+// it was loaded from one
+func sessionLogger(m *Maze) *slog.Logger {
+	l := logger.With("session", m.id, "client", m.client, "builder", m.builder)
+	if m.file != "" {
+		l = l.With("file", m.file)
+	}
+	return l
+}
+
+// logMaze dumps maze m at the debug level, in the format selected by
+// the pretty option
+func logMaze(m *Maze) {
+	if !logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+
+	var b strings.Builder
+	if viper.GetBool("pretty") {
+		mazelib.WritePrettyMaze(&b, m)
+	} else {
+		mazelib.WriteMaze(&b, m)
+	}
+
+	sessionLogger(m).Debug("new maze", "seed", m.seed, "maze", "\n"+b.String())
+}
+
+// logRequests is a middleware that logs every request at the debug
+// level
+func logRequests(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	logger.Debug("request",
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"status", c.Writer.Status(),
+		"duration", time.Since(start))
+}
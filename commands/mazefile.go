@@ -0,0 +1,140 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+)
+
+// mazeFile is a maze loaded from a file, served instead of a generated
+// one
+type mazeFile struct {
+	name      string
+	blueprint *mazelib.Blueprint
+}
+
+// mazeFiles holds the mazes given by the maze-file option, and
+// nextMazeFile the one to serve next. Like the tracker, nextMazeFile is
+// protected by mu.
+var mazeFiles []mazeFile
+var nextMazeFile int
+
+// loadMazeFiles reads the mazes given by the maze-file option, which
+// is either a single file or a directory holding several of them. All
+// the files in the directory must be mazes, except for hidden ones.
+func loadMazeFiles() error {
+	path := viper.GetString("maze-file")
+	if path == "" {
+		return nil
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	paths := []string{path}
+	if fi.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+
+		paths = paths[:0]
+		for _, e := range entries {
+			if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+				continue
+			}
+			paths = append(paths, filepath.Join(path, e.Name()))
+		}
+		sort.Strings(paths)
+
+		if len(paths) == 0 {
+			return fmt.Errorf("no mazes found in %s", path)
+		}
+	}
+
+	files := make([]mazeFile, 0, len(paths))
+	for _, p := range paths {
+		b, err := readMazeFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, mazeFile{name: filepath.Base(p), blueprint: b})
+	}
+
+	mazeFiles = files
+	nextMazeFile = 0
+	logger.Info("serving mazes from files", "path", path, "mazes", len(mazeFiles))
+
+	return nil
+}
+
+// readMazeFile reads the maze in the named file, in any of the formats
+// known to mazelib
+func readMazeFile(name string) (*mazelib.Blueprint, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := mazelib.ReadMaze(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	return b, nil
+}
+
+// fileBuilder is the builder of the mazes loaded from files. The names
+// of the files only go in the logs and the recordings, so that there's
+// a fixed number of builders in the metrics.
+const fileBuilder = "file"
+
+// pickMazeFile returns the next maze loaded from a file, going back to
+// the first one after the last. mu must be held.
+func pickMazeFile() (*Maze, error) {
+	f := mazeFiles[nextMazeFile]
+	nextMazeFile = (nextMazeFile + 1) % len(mazeFiles)
+
+	m, err := blueprintMaze(f.blueprint)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", f.name, err)
+	}
+	m.builder = fileBuilder
+	m.file = f.name
+	m.seed = f.blueprint.Seed
+
+	return m, nil
+}
+
+// blueprintMaze returns a new maze built from blueprint b
+func blueprintMaze(b *mazelib.Blueprint) (*Maze, error) {
+	m := newMaze(b.Width, b.Height)
+	if err := b.Build(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,104 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// The metrics exposed by daedalus at /metrics
+var (
+	mazesGenerated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "daedalus_mazes_generated_total",
+		Help: "Number of mazes generated, by builder.",
+	}, []string{"builder"})
+
+	movesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "daedalus_moves_total",
+		Help: "Number of moves Icarus tried, by result (accepted or rejected).",
+	}, []string{"result"})
+
+	victoriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "daedalus_victories_total",
+		Help: "Number of mazes solved, by builder.",
+	}, []string{"builder"})
+
+	victorySteps = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "daedalus_victory_steps",
+		Help:    "Steps taken to find the treasure, by builder.",
+		Buckets: prometheus.ExponentialBuckets(4, 2, 12),
+	}, []string{"builder"})
+
+	activeSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "daedalus_active_sessions",
+		Help: "Number of sessions in progress.",
+	})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "daedalus_http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+)
+
+// countMove records the result of a move in the metrics
+func countMove(accepted bool) {
+	if accepted {
+		movesTotal.WithLabelValues("accepted").Inc()
+	} else {
+		movesTotal.WithLabelValues("rejected").Inc()
+	}
+}
+
+// countVictory records the steps taken to solve maze m in the metrics
+func countVictory(m *Maze) {
+	victoriesTotal.WithLabelValues(m.builder).Inc()
+	victorySteps.WithLabelValues(m.builder).Observe(float64(m.StepsTaken))
+}
+
+// streamRoutes are the routes that stay open as long as the client
+// wants, so how long they take says nothing about the server
+var streamRoutes = map[string]bool{"/ws": true, "/events": true}
+
+// measure is a middleware that records how long requests take, except
+// for streamRoutes. Requests that don't match any route are lumped
+// together so that random addresses don't create new series.
+func measure(c *gin.Context) {
+	start := time.Now()
+
+	c.Next()
+
+	route := c.FullPath()
+	if streamRoutes[route] {
+		return
+	}
+	if route == "" {
+		route = "unmatched"
+	}
+
+	httpDuration.
+		WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+		Observe(time.Since(start).Seconds())
+}
+
+// The API response to the /metrics address
+var Metrics = gin.WrapH(promhttp.Handler())
@@ -0,0 +1,43 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMeasureSkipsStreams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(measure)
+	for _, route := range []string{"/healthz", "/ws", "/events"} {
+		r.GET(route, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+
+	for _, route := range []string{"/healthz", "/ws", "/events"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", route, nil))
+	}
+
+	if n := testutil.CollectAndCount(httpDuration); n != 1 {
+		t.Errorf("%d routes measured, expecting 1", n)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// recording writes the events of a session to a replay log, one JSON
+// object per line, the same ones that are sent to spectators.
+type recording struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+// Like the sessions, the recordings are protected by mu. recordStamp
+// is the time of the first recording, which names all the logs written
+// by this server.
+var (
+	recordings  = make(map[int]*recording)
+	recordStamp string
+)
+
+// startRecording opens the replay log for the session of maze m, if
+// recording was requested. mu must be held.
+func startRecording(m *Maze) {
+	dir := viper.GetString("record-dir")
+	if dir == "" {
+		return
+	}
+
+	if recordStamp == "" {
+		recordStamp = time.Now().Format("20060102-150405")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		sessionLogger(m).Error("can't record session", "err", err)
+		return
+	}
+
+	name := filepath.Join(dir, fmt.Sprintf("%s-%d.jsonl", recordStamp, m.id))
+	f, err := os.Create(name)
+	if err != nil {
+		sessionLogger(m).Error("can't record session", "err", err)
+		return
+	}
+
+	w := bufio.NewWriter(f)
+	recordings[m.id] = &recording{f: f, w: w, enc: json.NewEncoder(w)}
+}
+
+// record writes e to the replay log of its session, if there's one. A
+// log that can't be written to is abandoned. mu must be held.
+func record(e event) {
+	r, ok := recordings[e.Session]
+	if !ok {
+		return
+	}
+
+	if err := r.enc.Encode(e); err != nil {
+		logger.Error("can't record session", "session", e.Session, "err", err)
+		delete(recordings, e.Session)
+		r.f.Close()
+	}
+}
+
+// stopRecording closes the replay log for the session of maze m. mu
+// must be held.
+func stopRecording(m *Maze) {
+	r, ok := recordings[m.id]
+	if !ok {
+		return
+	}
+
+	delete(recordings, m.id)
+	r.close()
+}
+
+// closeRecordings closes the replay logs of the sessions still in
+// progress
+func closeRecordings() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for id, r := range recordings {
+		delete(recordings, id)
+		r.close()
+	}
+}
+
+// close flushes the log and closes the file
+func (r *recording) close() {
+	if err := r.w.Flush(); err != nil {
+		logger.Error("can't record session", "file", r.f.Name(), "err", err)
+	}
+	r.f.Close()
+}
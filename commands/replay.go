@@ -0,0 +1,234 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay <file>",
+	Short: "Replay a session recorded by daedalus",
+	Long: `Replay steps through a session recorded by daedalus with --record-dir,
+  drawing the maze after every move Icarus made.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		if err := Replay(args[0]); err != nil {
+			logger.Error("can't replay session", "file", args[0], "err", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	replayCmd.Flags().Float64("speed", 10, "Moves per second (0 draws them as fast as possible)")
+	replayCmd.Flags().Int("step", -1, "Only draw the maze after this many moves")
+
+	viper.BindPFlag("speed", replayCmd.Flags().Lookup("speed"))
+	viper.BindPFlag("step", replayCmd.Flags().Lookup("step"))
+
+	addRenderFlags(replayCmd)
+
+	RootCmd.AddCommand(replayCmd)
+}
+
+// Replay reads the session log in file and draws the maze after each
+// move, or only after the move given by the step option.
+func Replay(file string) error {
+	events, err := readRecording(file)
+	if err != nil {
+		return err
+	}
+
+	if len(events) == 0 || events[0].Type != "maze" || events[0].Maze == nil {
+		return errors.New("replay: log doesn't start with a maze")
+	}
+
+	m, err := layoutMaze(events[0].Maze)
+	if err != nil {
+		return err
+	}
+
+	var moves []event
+	for _, e := range events[1:] {
+		if e.Type == "move" {
+			moves = append(moves, e)
+		}
+	}
+
+	step := viper.GetInt("step")
+	if step > len(moves) {
+		return fmt.Errorf("replay: the session has only %d moves", len(moves))
+	}
+
+	if rendering() {
+		return renderReplay(m, moves, step)
+	}
+
+	var delay time.Duration
+	if speed := viper.GetFloat64("speed"); speed > 0 && step < 0 {
+		delay = time.Duration(float64(time.Second) / speed)
+	}
+
+	l := events[0].Maze
+	header := fmt.Sprintf("Session %d, %dx%d maze by the %s builder (seed %d)",
+		events[0].Session, l.Width, l.Height, l.Builder, l.Seed)
+	if l.File != "" {
+		header = fmt.Sprintf("Session %d, %dx%d maze from %s", events[0].Session, l.Width, l.Height, l.File)
+	}
+
+	draw := func(n int) {
+		if delay > 0 {
+			// clear the screen so the maze is drawn in place
+			fmt.Print("\033[H\033[2J")
+		}
+		fmt.Println(header)
+		if n == 0 {
+			fmt.Println("Start")
+		} else {
+			e := moves[n-1]
+			status := ""
+			switch {
+			case e.Victory:
+				status = " (victory)"
+			case e.Error != "":
+				status = " (" + e.Error + ")"
+			}
+			fmt.Printf("Move %d/%d: %s%s\n", n, len(moves), e.Direction, status)
+		}
+		mazelib.PrintPrettyMaze(m)
+	}
+
+	if step <= 0 {
+		draw(0)
+	}
+
+	for i, e := range moves {
+		if i >= step && step >= 0 {
+			break
+		}
+
+		m.icarus = e.Icarus
+		if r, err := m.GetRoom(e.Icarus.X, e.Icarus.Y); err == nil {
+			r.Visited = true
+		}
+		m.StepsTaken = e.Steps
+
+		if step < 0 || i+1 == step {
+			time.Sleep(delay)
+			draw(i + 1)
+		}
+	}
+
+	return nil
+}
+
+// renderReplay writes the drawings asked for by the render flags of
+// the path Icarus followed in maze m, up to the given step, or all of
+// it if step is negative. The animation plays at the pace given by the
+// speed option.
+func renderReplay(m *Maze, moves []event, step int) error {
+	if step >= 0 {
+		moves = moves[:step]
+	}
+
+	var path []int
+	for _, e := range moves {
+		if e.Error != "" {
+			continue
+		}
+		dir, err := mazelib.ParseDirection(e.Direction)
+		if err != nil {
+			return fmt.Errorf("replay: %v", err)
+		}
+		path = append(path, dir)
+	}
+
+	var delay time.Duration
+	if speed := viper.GetFloat64("speed"); speed > 0 {
+		delay = time.Duration(float64(time.Second) / speed)
+	}
+
+	return renderSolve(m, path, delay)
+}
+
+// readRecording reads all the events in the session log in file
+func readRecording(file string) ([]event, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []event
+
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 16*1024*1024)
+	for s.Scan() {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var e event
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("replay: %v", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, s.Err()
+}
+
+// layoutMaze recreates the maze described by l
+func layoutMaze(l *layout) (*Maze, error) {
+	if l.Width <= 0 || l.Height <= 0 || len(l.Walls) != l.Height {
+		return nil, errors.New("replay: invalid maze layout")
+	}
+
+	m := &Maze{builder: l.Builder, file: l.File, seed: l.Seed}
+	m.rooms = make([][]mazelib.Room, l.Height)
+	for y := range m.rooms {
+		if len(l.Walls[y]) != l.Width {
+			return nil, errors.New("replay: invalid maze layout")
+		}
+		m.rooms[y] = make([]mazelib.Room, l.Width)
+		for x := range m.rooms[y] {
+			m.rooms[y][x].Walls = l.Walls[y][x]
+		}
+	}
+
+	if err := m.SetStartPoint(l.Start.X, l.Start.Y); err != nil {
+		return nil, err
+	}
+	if err := m.SetTreasure(l.Treasure.X, l.Treasure.Y); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
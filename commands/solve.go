@@ -0,0 +1,230 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mem/labyrinth/client"
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// solveCmd represents the solve command
+var solveCmd = &cobra.Command{
+	Use:   "solve <file>",
+	Short: "Run a solver against a maze file",
+	Long: `Solve runs the solver chosen with --solver against the maze in file,
+  within the same process and without daedalus, and shows how it did:
+  the steps it took, the path it followed and how that compares to the
+  shortest path to the treasure.
+
+  The maze can be in any of the formats written by generate.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			cmd.Usage()
+			os.Exit(-1)
+		}
+
+		if err := Solve(args[0]); err != nil {
+			logger.Error("can't solve maze", "file", args[0], "err", err)
+			os.Exit(-1)
+		}
+	},
+}
+
+func init() {
+	solveCmd.Flags().Bool("draw", false, "Draw the maze with the rooms Icarus explored")
+
+	addRenderFlags(solveCmd)
+
+	viper.BindPFlag("draw", solveCmd.Flags().Lookup("draw"))
+
+	RootCmd.AddCommand(solveCmd)
+}
+
+// Solve runs the configured solver against the maze in file and prints
+// the outcome, along with the drawings asked for. If the solver fails,
+// they show how far it got before the error is returned.
+func Solve(file string) error {
+	b, err := readMazeFile(file)
+	if err != nil {
+		return err
+	}
+
+	m, err := blueprintMaze(b)
+	if err != nil {
+		return err
+	}
+	t := &offlineTransport{m: m}
+
+	c := client.NewWithTransport(t)
+	c.MaxSteps = viper.GetInt("max-steps")
+
+	// even if the solver fails, show how far it got
+	solveErr := solveMaze(context.Background(), c)
+
+	_, victory := m.LookAround()
+
+	r := result{
+		Steps:   m.StepsTaken,
+		Optimal: len(mazelib.ShortestPath(m, b.Start, b.Treasure)),
+	}
+
+	fmt.Printf("solver:     %s\n", viper.GetString("solver"))
+	fmt.Printf("maze:       %dx%d\n", b.Width, b.Height)
+	fmt.Printf("solved:     %t\n", victory == mazelib.ErrVictory)
+	fmt.Printf("steps:      %d\n", r.Steps)
+	fmt.Printf("optimal:    %d\n", r.Optimal)
+	if victory == mazelib.ErrVictory {
+		fmt.Printf("efficiency: %.2f\n", r.efficiency())
+	}
+	fmt.Printf("path:       %s\n", mazelib.FormatPath(t.path))
+
+	if viper.GetBool("draw") {
+		fmt.Println()
+		if err := mazelib.WritePrettyMaze(os.Stdout, m); err != nil {
+			return errors.Join(solveErr, err)
+		}
+	}
+
+	return errors.Join(solveErr, renderSolve(m, t.path, 100*time.Millisecond))
+}
+
+// renderFlags are the flags used by renderSolve
+var renderFlags = []string{"svg", "png", "gif"}
+
+// addRenderFlags adds the flags used by renderSolve to cmd. Since
+// several commands have them, they are bound to the configuration only
+// once cmd runs.
+func addRenderFlags(cmd *cobra.Command) {
+	cmd.Flags().String("svg", "", "Write an SVG drawing of the maze, the path taken and the shortest one to this file")
+	cmd.Flags().String("png", "", "Write a PNG image like the SVG drawing to this file")
+	cmd.Flags().String("gif", "", "Write an animation of Icarus solving the maze to this file")
+
+	cmd.PreRun = func(cmd *cobra.Command, args []string) {
+		for _, name := range renderFlags {
+			viper.BindPFlag(name, cmd.Flags().Lookup(name))
+		}
+	}
+}
+
+// rendering returns true if any of the render flags was given
+func rendering() bool {
+	for _, name := range renderFlags {
+		if viper.GetString(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// renderSolve writes the drawings of Icarus following path in maze m
+// asked for by the svg, png and gif options. The animation shows each
+// move for delay.
+func renderSolve(m *Maze, path []int, delay time.Duration) error {
+	o := mazelib.RenderOptions{Optimal: true, Trajectory: path, Heatmap: true}
+
+	renderers := []struct {
+		option string
+		write  func(w io.Writer) error
+	}{
+		{"svg", func(w io.Writer) error { return mazelib.WriteSVG(w, m, o) }},
+		{"png", func(w io.Writer) error { return mazelib.WritePNG(w, m, o) }},
+		{"gif", func(w io.Writer) error { return mazelib.WriteGIF(w, m, o, delay) }},
+	}
+
+	for _, r := range renderers {
+		if file := viper.GetString(r.option); file != "" {
+			if err := writeFile(file, r.write); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// offlineTransport lets a client solve a single maze directly, without
+// daedalus. It keeps the path taken by Icarus and marks the rooms he
+// visits.
+type offlineTransport struct {
+	m    *Maze
+	path []int
+}
+
+// Awake implements client.Transport
+func (t *offlineTransport) Awake(ctx context.Context) (mazelib.Reply, error) {
+	return t.survey(), nil
+}
+
+// Move implements client.Transport
+func (t *offlineTransport) Move(ctx context.Context, dir int) (mazelib.Reply, error) {
+	if err := t.m.Move(dir); err != nil {
+		return mazelib.Reply{Error: true, Message: err.Error()}, nil
+	}
+
+	t.path = append(t.path, dir)
+
+	return t.survey(), nil
+}
+
+// MovePath implements client.Transport
+func (t *offlineTransport) MovePath(ctx context.Context, path []int) (mazelib.BatchReply, error) {
+	b := mazelib.BatchReply{Replies: make([]mazelib.Reply, 0, len(path))}
+
+	for _, dir := range path {
+		r, _ := t.Move(ctx, dir)
+		b.Replies = append(b.Replies, r)
+		if r.Error {
+			break
+		}
+		b.Taken++
+		if r.Victory {
+			break
+		}
+	}
+
+	return b, nil
+}
+
+// Done implements client.Transport
+func (t *offlineTransport) Done(ctx context.Context) error {
+	return nil
+}
+
+// survey marks the room Icarus is in as visited and returns the reply
+// describing it
+func (t *offlineTransport) survey() mazelib.Reply {
+	x, y := t.m.Icarus()
+	t.m.rooms[y][x].Visited = true
+
+	s, err := t.m.LookAround()
+	switch err {
+	case nil:
+		return mazelib.Reply{Survey: s}
+	case mazelib.ErrVictory:
+		return mazelib.Reply{Survey: s, Victory: true}
+	}
+
+	return mazelib.Reply{Error: true, Message: err.Error()}
+}
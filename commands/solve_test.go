@@ -0,0 +1,81 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/mem/labyrinth/client"
+	"github.com/mem/labyrinth/mazelib"
+	"github.com/spf13/viper"
+)
+
+// solveSteps builds a maze with builder and seed, and returns the steps
+// taken by solver to find the treasure in it
+func solveSteps(t *testing.T, solver, builder string, seed int64) int {
+	viper.Set("solver", solver)
+
+	m, err := buildMaze(builder, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := client.NewWithTransport(&offlineTransport{m: m})
+	if err := solveMaze(context.Background(), c); err != nil {
+		t.Fatalf("%s, %s maze %d: %v", solver, builder, seed, err)
+	}
+
+	if _, err := m.LookAround(); err != mazelib.ErrVictory {
+		t.Fatalf("%s didn't solve %s maze %d", solver, builder, seed)
+	}
+
+	return m.StepsTaken
+}
+
+// TestExplorerBeatsRecursive checks that the explorer takes fewer steps
+// than the recursive solver. Without loops, where there's no shortcut to
+// find, it takes about as many; with them, a lot fewer.
+func TestExplorerBeatsRecursive(t *testing.T) {
+	viper.Set("width", 40)
+	viper.Set("height", 30)
+	defer viper.Reset()
+
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	defer func() { logger = slog.Default() }()
+
+	var explorer, recursive int
+	for _, name := range builderNames {
+		var e, r int
+		for seed := int64(1); seed <= 50; seed++ {
+			e += solveSteps(t, "explorer", name, seed)
+			r += solveSteps(t, "recursive", name, seed)
+		}
+
+		t.Logf("%s mazes: explorer took %d steps, recursive %d", name, e, r)
+		if e > r+r/20 || (name == "ring" && e >= r) {
+			t.Errorf("%s mazes: explorer took %d steps, recursive %d", name, e, r)
+		}
+
+		explorer += e
+		recursive += r
+	}
+
+	if explorer >= recursive {
+		t.Errorf("explorer took %d steps, recursive %d", explorer, recursive)
+	}
+}
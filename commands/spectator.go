@@ -0,0 +1,231 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mem/labyrinth/mazelib"
+)
+
+// event is sent to spectators whenever something happens in a session.
+// A "maze" event carries the layout of the maze, "move" events carry
+// the direction Icarus tried to move in and his new position (or why he
+// couldn't move) and "end" events tell that the session is gone.
+type event struct {
+	Session   int                `json:"session"`
+	Type      string             `json:"type"`
+	Maze      *layout            `json:"maze,omitempty"`
+	Direction string             `json:"direction,omitempty"`
+	Icarus    mazelib.Coordinate `json:"icarus"`
+	Steps     int                `json:"steps"`
+	Victory   bool               `json:"victory"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// layout describes a maze for the benefit of spectators
+type layout struct {
+	Width    int                `json:"width"`
+	Height   int                `json:"height"`
+	Walls    [][]mazelib.Survey `json:"walls"`
+	Start    mazelib.Coordinate `json:"start"`
+	Treasure mazelib.Coordinate `json:"treasure"`
+	Builder  string             `json:"builder"`
+	File     string             `json:"file,omitempty"`
+	Seed     int64              `json:"seed"`
+	Client   string             `json:"client"`
+}
+
+// spectator receives the events for the sessions it's watching. A
+// session of 0 means all of them.
+type spectator struct {
+	session int
+	events  chan event
+}
+
+// Like everything else that tracks mazes, the sessions and the
+// spectators are protected by mu.
+var (
+	sessions    = make(map[int]*Maze)
+	lastSession int
+	spectators  = make(map[*spectator]bool)
+)
+
+// newSession creates a new maze for a session of the named client,
+// replacing old (if not nil), and lets the spectators know about it.
+// It fails if the client is already playing too many mazes. mu must be
+// held.
+func newSession(old *Maze, client string) (*Maze, error) {
+	endSession(old)
+
+	if err := checkSessions(client); err != nil {
+		return nil, err
+	}
+
+	m, err := createMaze()
+	if err != nil {
+		return nil, err
+	}
+	lastSession++
+	m.id = lastSession
+	m.client = client
+	m.lastActive = clock()
+	sessions[m.id] = m
+
+	mazesGenerated.WithLabelValues(m.builder).Inc()
+	activeSessions.Set(float64(len(sessions)))
+
+	startRecording(m)
+	publish(mazeEvent(m))
+
+	return m, nil
+}
+
+// endSession lets the spectators know that the session for maze m is
+// gone, unless it's already over. mu must be held.
+func endSession(m *Maze) {
+	if m == nil || !active(m) {
+		return
+	}
+
+	delete(sessions, m.id)
+	activeSessions.Set(float64(len(sessions)))
+	publish(event{Session: m.id, Type: "end", Icarus: m.icarus, Steps: m.StepsTaken})
+	stopRecording(m)
+}
+
+// moveEvent lets the spectators know that Icarus tried to move in
+// direction dir in maze m, and err tells why he couldn't. mu must be
+// held.
+func moveEvent(m *Maze, dir int, victory bool, err error) {
+	e := event{
+		Session:   m.id,
+		Type:      "move",
+		Direction: mazelib.DirectionName(dir),
+		Icarus:    m.icarus,
+		Steps:     m.StepsTaken,
+		Victory:   victory,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	publish(e)
+}
+
+// mazeEvent returns an event describing maze m and the current
+// position of Icarus in it
+func mazeEvent(m *Maze) event {
+	l := &layout{
+		Width:    m.Width(),
+		Height:   m.Height(),
+		Walls:    make([][]mazelib.Survey, m.Height()),
+		Start:    m.start,
+		Treasure: m.end,
+		Builder:  m.builder,
+		File:     m.file,
+		Seed:     m.seed,
+		Client:   m.client,
+	}
+
+	for y := range l.Walls {
+		l.Walls[y] = make([]mazelib.Survey, m.Width())
+		for x := range l.Walls[y] {
+			l.Walls[y][x] = m.rooms[y][x].Walls
+		}
+	}
+
+	return event{Session: m.id, Type: "maze", Maze: l, Icarus: m.icarus, Steps: m.StepsTaken}
+}
+
+// publish sends e to the spectators watching its session, and records
+// it if the session is being recorded. Spectators that can't keep up
+// miss events instead of slowing down the game. mu must be held.
+func publish(e event) {
+	record(e)
+
+	for s := range spectators {
+		if s.session != 0 && s.session != e.Session {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+		}
+	}
+}
+
+// closeSpectators disconnects all the spectators
+func closeSpectators() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for s := range spectators {
+		close(s.events)
+		delete(spectators, s)
+	}
+}
+
+// The API response to the /events address
+//
+// Events streams the events for all the sessions using Server-Sent
+// Events, or just the ones for the session given by the session query
+// parameter. The layout of the mazes in progress is sent first, then
+// the moves as they happen.
+func Events(c *gin.Context) {
+	s := &spectator{}
+
+	if q := c.Query("session"); q != "" {
+		id, err := strconv.Atoi(q)
+		if err != nil || id <= 0 {
+			c.JSON(http.StatusBadRequest, mazelib.Reply{Error: true, Message: "invalid session"})
+			return
+		}
+		s.session = id
+	}
+
+	mu.Lock()
+	// make room for the current mazes on top of the usual backlog
+	s.events = make(chan event, len(sessions)+1024)
+	for id, m := range sessions {
+		if s.session == 0 || s.session == id {
+			s.events <- mazeEvent(m)
+		}
+	}
+	spectators[s] = true
+	mu.Unlock()
+
+	defer func() {
+		mu.Lock()
+		delete(spectators, s)
+		mu.Unlock()
+	}()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case e, ok := <-s.events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(e.Type, e)
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
@@ -0,0 +1,34 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// viewerPage is a self-contained page that draws the mazes and
+// animates Icarus's moves using the events sent to spectators
+//
+//go:embed viewer/index.html
+var viewerPage []byte
+
+// The API response to the /viewer address
+func Viewer(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", viewerPage)
+}
@@ -0,0 +1,119 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package commands
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/mem/labyrinth/mazelib"
+)
+
+var upgrader = websocket.Upgrader{}
+
+var errInvalidAction = errors.New("invalid action")
+
+// The API response to the /ws address
+//
+// Play upgrades the connection to a websocket and runs a session over
+// it. The client sends mazelib.Request messages and the server answers
+// each one of them with a mazelib.Reply, or a mazelib.BatchReply when
+// moving several steps at once. Each connection gets its own maze,
+// which is discarded when the connection is closed, so many clients
+// can play at the same time.
+func Play(c *gin.Context) {
+	name := clientName(c)
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// the upgrader already replied to the client
+		return
+	}
+	defer conn.Close()
+
+	var m *Maze
+	defer closeSession(&m)
+
+	for {
+		var req mazelib.Request
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		r := playRequest(&m, req, name)
+
+		if err := conn.WriteJSON(r); err != nil {
+			return
+		}
+
+		if req.Action == "done" {
+			conn.WriteMessage(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+	}
+}
+
+// closeSession ends the session for the maze in m, if any
+func closeSession(m **Maze) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	endSession(*m)
+	*m = nil
+}
+
+// playRequest handles a single request made over a websocket
+// connection and returns the reply, which is a mazelib.BatchReply for
+// "moves" and a mazelib.Reply for everything else. m is the maze for
+// the session, which is replaced when Icarus wakes up, and client is the
+// name of the client playing it.
+func playRequest(m **Maze, req mazelib.Request, client string) interface{} {
+	mu.Lock()
+	defer mu.Unlock()
+
+	switch req.Action {
+	case "awake":
+		var err error
+		if *m, err = newSession(*m, client); err != nil {
+			return mazelib.Reply{Error: true, Message: err.Error()}
+		}
+		_, r := startingPoint(*m)
+		return r
+
+	case "move":
+		dir, err := mazelib.ParseDirection(req.Direction)
+		if err != nil {
+			return mazelib.Reply{Error: true, Message: err.Error()}
+		}
+		_, r := moveIcarus(*m, dir)
+		return r
+
+	case "moves":
+		path, err := parseDirections(req.Path)
+		if err != nil {
+			r := mazelib.Reply{Error: true, Message: err.Error()}
+			return mazelib.BatchReply{Replies: []mazelib.Reply{r}}
+		}
+		return moveIcarusPath(*m, path)
+
+	case "done":
+		return mazelib.Reply{Message: "Goodbye"}
+	}
+
+	return mazelib.Reply{Error: true, Message: errInvalidAction.Error()}
+}
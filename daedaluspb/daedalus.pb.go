@@ -0,0 +1,556 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: daedalus.proto
+
+package daedaluspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Direction is the direction Icarus moves in. Its values are mapped
+// to the mazelib constants by name, not by number.
+type Direction int32
+
+const (
+	Direction_DIRECTION_UNSPECIFIED Direction = 0
+	Direction_DIRECTION_UP          Direction = 1
+	Direction_DIRECTION_DOWN        Direction = 2
+	Direction_DIRECTION_RIGHT       Direction = 3
+	Direction_DIRECTION_LEFT        Direction = 4
+)
+
+// Enum value maps for Direction.
+var (
+	Direction_name = map[int32]string{
+		0: "DIRECTION_UNSPECIFIED",
+		1: "DIRECTION_UP",
+		2: "DIRECTION_DOWN",
+		3: "DIRECTION_RIGHT",
+		4: "DIRECTION_LEFT",
+	}
+	Direction_value = map[string]int32{
+		"DIRECTION_UNSPECIFIED": 0,
+		"DIRECTION_UP":          1,
+		"DIRECTION_DOWN":        2,
+		"DIRECTION_RIGHT":       3,
+		"DIRECTION_LEFT":        4,
+	}
+)
+
+func (x Direction) Enum() *Direction {
+	p := new(Direction)
+	*p = x
+	return p
+}
+
+func (x Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_daedalus_proto_enumTypes[0].Descriptor()
+}
+
+func (Direction) Type() protoreflect.EnumType {
+	return &file_daedalus_proto_enumTypes[0]
+}
+
+func (x Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Direction.Descriptor instead.
+func (Direction) EnumDescriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{0}
+}
+
+// Survey tells which walls are present around a room.
+type Survey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Top           bool                   `protobuf:"varint,1,opt,name=top,proto3" json:"top,omitempty"`
+	Right         bool                   `protobuf:"varint,2,opt,name=right,proto3" json:"right,omitempty"`
+	Bottom        bool                   `protobuf:"varint,3,opt,name=bottom,proto3" json:"bottom,omitempty"`
+	Left          bool                   `protobuf:"varint,4,opt,name=left,proto3" json:"left,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Survey) Reset() {
+	*x = Survey{}
+	mi := &file_daedalus_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Survey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Survey) ProtoMessage() {}
+
+func (x *Survey) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Survey.ProtoReflect.Descriptor instead.
+func (*Survey) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Survey) GetTop() bool {
+	if x != nil {
+		return x.Top
+	}
+	return false
+}
+
+func (x *Survey) GetRight() bool {
+	if x != nil {
+		return x.Right
+	}
+	return false
+}
+
+func (x *Survey) GetBottom() bool {
+	if x != nil {
+		return x.Bottom
+	}
+	return false
+}
+
+func (x *Survey) GetLeft() bool {
+	if x != nil {
+		return x.Left
+	}
+	return false
+}
+
+// Reply mirrors mazelib.Reply.
+type Reply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Survey        *Survey                `protobuf:"bytes,1,opt,name=survey,proto3" json:"survey,omitempty"`
+	Victory       bool                   `protobuf:"varint,2,opt,name=victory,proto3" json:"victory,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Error         bool                   `protobuf:"varint,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reply) Reset() {
+	*x = Reply{}
+	mi := &file_daedalus_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reply) ProtoMessage() {}
+
+func (x *Reply) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reply.ProtoReflect.Descriptor instead.
+func (*Reply) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Reply) GetSurvey() *Survey {
+	if x != nil {
+		return x.Survey
+	}
+	return nil
+}
+
+func (x *Reply) GetVictory() bool {
+	if x != nil {
+		return x.Victory
+	}
+	return false
+}
+
+func (x *Reply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Reply) GetError() bool {
+	if x != nil {
+		return x.Error
+	}
+	return false
+}
+
+type AwakeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AwakeRequest) Reset() {
+	*x = AwakeRequest{}
+	mi := &file_daedalus_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AwakeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AwakeRequest) ProtoMessage() {}
+
+func (x *AwakeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AwakeRequest.ProtoReflect.Descriptor instead.
+func (*AwakeRequest) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{2}
+}
+
+type MoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Direction     Direction              `protobuf:"varint,1,opt,name=direction,proto3,enum=labyrinth.daedalus.Direction" json:"direction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveRequest) Reset() {
+	*x = MoveRequest{}
+	mi := &file_daedalus_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveRequest) ProtoMessage() {}
+
+func (x *MoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveRequest.ProtoReflect.Descriptor instead.
+func (*MoveRequest) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MoveRequest) GetDirection() Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return Direction_DIRECTION_UNSPECIFIED
+}
+
+type DoneRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DoneRequest) Reset() {
+	*x = DoneRequest{}
+	mi := &file_daedalus_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DoneRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DoneRequest) ProtoMessage() {}
+
+func (x *DoneRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DoneRequest.ProtoReflect.Descriptor instead.
+func (*DoneRequest) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{4}
+}
+
+type PlayRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Action:
+	//
+	//	*PlayRequest_Awake
+	//	*PlayRequest_Move
+	//	*PlayRequest_Done
+	Action        isPlayRequest_Action `protobuf_oneof:"action"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlayRequest) Reset() {
+	*x = PlayRequest{}
+	mi := &file_daedalus_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayRequest) ProtoMessage() {}
+
+func (x *PlayRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayRequest.ProtoReflect.Descriptor instead.
+func (*PlayRequest) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PlayRequest) GetAction() isPlayRequest_Action {
+	if x != nil {
+		return x.Action
+	}
+	return nil
+}
+
+func (x *PlayRequest) GetAwake() *AwakeRequest {
+	if x != nil {
+		if x, ok := x.Action.(*PlayRequest_Awake); ok {
+			return x.Awake
+		}
+	}
+	return nil
+}
+
+func (x *PlayRequest) GetMove() *MoveRequest {
+	if x != nil {
+		if x, ok := x.Action.(*PlayRequest_Move); ok {
+			return x.Move
+		}
+	}
+	return nil
+}
+
+func (x *PlayRequest) GetDone() *DoneRequest {
+	if x != nil {
+		if x, ok := x.Action.(*PlayRequest_Done); ok {
+			return x.Done
+		}
+	}
+	return nil
+}
+
+type isPlayRequest_Action interface {
+	isPlayRequest_Action()
+}
+
+type PlayRequest_Awake struct {
+	Awake *AwakeRequest `protobuf:"bytes,1,opt,name=awake,proto3,oneof"`
+}
+
+type PlayRequest_Move struct {
+	Move *MoveRequest `protobuf:"bytes,2,opt,name=move,proto3,oneof"`
+}
+
+type PlayRequest_Done struct {
+	Done *DoneRequest `protobuf:"bytes,3,opt,name=done,proto3,oneof"`
+}
+
+func (*PlayRequest_Awake) isPlayRequest_Action() {}
+
+func (*PlayRequest_Move) isPlayRequest_Action() {}
+
+func (*PlayRequest_Done) isPlayRequest_Action() {}
+
+var File_daedalus_proto protoreflect.FileDescriptor
+
+const file_daedalus_proto_rawDesc = "" +
+	"\n" +
+	"\x0edaedalus.proto\x12\x12labyrinth.daedalus\"\\\n" +
+	"\x06Survey\x12\x10\n" +
+	"\x03top\x18\x01 \x01(\bR\x03top\x12\x14\n" +
+	"\x05right\x18\x02 \x01(\bR\x05right\x12\x16\n" +
+	"\x06bottom\x18\x03 \x01(\bR\x06bottom\x12\x12\n" +
+	"\x04left\x18\x04 \x01(\bR\x04left\"\x85\x01\n" +
+	"\x05Reply\x122\n" +
+	"\x06survey\x18\x01 \x01(\v2\x1a.labyrinth.daedalus.SurveyR\x06survey\x12\x18\n" +
+	"\avictory\x18\x02 \x01(\bR\avictory\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\bR\x05error\"\x0e\n" +
+	"\fAwakeRequest\"J\n" +
+	"\vMoveRequest\x12;\n" +
+	"\tdirection\x18\x01 \x01(\x0e2\x1d.labyrinth.daedalus.DirectionR\tdirection\"\r\n" +
+	"\vDoneRequest\"\xbf\x01\n" +
+	"\vPlayRequest\x128\n" +
+	"\x05awake\x18\x01 \x01(\v2 .labyrinth.daedalus.AwakeRequestH\x00R\x05awake\x125\n" +
+	"\x04move\x18\x02 \x01(\v2\x1f.labyrinth.daedalus.MoveRequestH\x00R\x04move\x125\n" +
+	"\x04done\x18\x03 \x01(\v2\x1f.labyrinth.daedalus.DoneRequestH\x00R\x04doneB\b\n" +
+	"\x06action*u\n" +
+	"\tDirection\x12\x19\n" +
+	"\x15DIRECTION_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fDIRECTION_UP\x10\x01\x12\x12\n" +
+	"\x0eDIRECTION_DOWN\x10\x02\x12\x13\n" +
+	"\x0fDIRECTION_RIGHT\x10\x03\x12\x12\n" +
+	"\x0eDIRECTION_LEFT\x10\x042\xa0\x02\n" +
+	"\bDaedalus\x12D\n" +
+	"\x05Awake\x12 .labyrinth.daedalus.AwakeRequest\x1a\x19.labyrinth.daedalus.Reply\x12B\n" +
+	"\x04Move\x12\x1f.labyrinth.daedalus.MoveRequest\x1a\x19.labyrinth.daedalus.Reply\x12B\n" +
+	"\x04Done\x12\x1f.labyrinth.daedalus.DoneRequest\x1a\x19.labyrinth.daedalus.Reply\x12F\n" +
+	"\x04Play\x12\x1f.labyrinth.daedalus.PlayRequest\x1a\x19.labyrinth.daedalus.Reply(\x010\x01B%Z#github.com/mem/labyrinth/daedaluspbb\x06proto3"
+
+var (
+	file_daedalus_proto_rawDescOnce sync.Once
+	file_daedalus_proto_rawDescData []byte
+)
+
+func file_daedalus_proto_rawDescGZIP() []byte {
+	file_daedalus_proto_rawDescOnce.Do(func() {
+		file_daedalus_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_daedalus_proto_rawDesc), len(file_daedalus_proto_rawDesc)))
+	})
+	return file_daedalus_proto_rawDescData
+}
+
+var file_daedalus_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_daedalus_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_daedalus_proto_goTypes = []any{
+	(Direction)(0),       // 0: labyrinth.daedalus.Direction
+	(*Survey)(nil),       // 1: labyrinth.daedalus.Survey
+	(*Reply)(nil),        // 2: labyrinth.daedalus.Reply
+	(*AwakeRequest)(nil), // 3: labyrinth.daedalus.AwakeRequest
+	(*MoveRequest)(nil),  // 4: labyrinth.daedalus.MoveRequest
+	(*DoneRequest)(nil),  // 5: labyrinth.daedalus.DoneRequest
+	(*PlayRequest)(nil),  // 6: labyrinth.daedalus.PlayRequest
+}
+var file_daedalus_proto_depIdxs = []int32{
+	1, // 0: labyrinth.daedalus.Reply.survey:type_name -> labyrinth.daedalus.Survey
+	0, // 1: labyrinth.daedalus.MoveRequest.direction:type_name -> labyrinth.daedalus.Direction
+	3, // 2: labyrinth.daedalus.PlayRequest.awake:type_name -> labyrinth.daedalus.AwakeRequest
+	4, // 3: labyrinth.daedalus.PlayRequest.move:type_name -> labyrinth.daedalus.MoveRequest
+	5, // 4: labyrinth.daedalus.PlayRequest.done:type_name -> labyrinth.daedalus.DoneRequest
+	3, // 5: labyrinth.daedalus.Daedalus.Awake:input_type -> labyrinth.daedalus.AwakeRequest
+	4, // 6: labyrinth.daedalus.Daedalus.Move:input_type -> labyrinth.daedalus.MoveRequest
+	5, // 7: labyrinth.daedalus.Daedalus.Done:input_type -> labyrinth.daedalus.DoneRequest
+	6, // 8: labyrinth.daedalus.Daedalus.Play:input_type -> labyrinth.daedalus.PlayRequest
+	2, // 9: labyrinth.daedalus.Daedalus.Awake:output_type -> labyrinth.daedalus.Reply
+	2, // 10: labyrinth.daedalus.Daedalus.Move:output_type -> labyrinth.daedalus.Reply
+	2, // 11: labyrinth.daedalus.Daedalus.Done:output_type -> labyrinth.daedalus.Reply
+	2, // 12: labyrinth.daedalus.Daedalus.Play:output_type -> labyrinth.daedalus.Reply
+	9, // [9:13] is the sub-list for method output_type
+	5, // [5:9] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_daedalus_proto_init() }
+func file_daedalus_proto_init() {
+	if File_daedalus_proto != nil {
+		return
+	}
+	file_daedalus_proto_msgTypes[5].OneofWrappers = []any{
+		(*PlayRequest_Awake)(nil),
+		(*PlayRequest_Move)(nil),
+		(*PlayRequest_Done)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_daedalus_proto_rawDesc), len(file_daedalus_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_daedalus_proto_goTypes,
+		DependencyIndexes: file_daedalus_proto_depIdxs,
+		EnumInfos:         file_daedalus_proto_enumTypes,
+		MessageInfos:      file_daedalus_proto_msgTypes,
+	}.Build()
+	File_daedalus_proto = out.File
+	file_daedalus_proto_goTypes = nil
+	file_daedalus_proto_depIdxs = nil
+}
@@ -0,0 +1,274 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseMaze reads a maze drawn in the format used by PrintMaze or by
+// PrintPrettyMaze, telling them apart by the first character. Use
+// Blueprint.Build to turn it into a playable maze.
+//
+// To make it easier to draw mazes by hand, S and T can be used instead
+// of the glyphs for the start and the treasure. In the PrintMaze format
+// the outer walls are always there, since it can't tell otherwise.
+func ParseMaze(r io.Reader) (*Blueprint, error) {
+	var lines [][]rune
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \t\r")
+		if line == "" && len(lines) == 0 {
+			// skip leading blank lines
+			continue
+		}
+		lines = append(lines, []rune(line))
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	// skip trailing blank lines
+	for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("no maze found")
+	}
+
+	var b *Blueprint
+	var err error
+
+	switch lines[0][0] {
+	case '_':
+		b, err = parsePlain(lines)
+	case '▛':
+		b, err = parsePretty(lines)
+	default:
+		return nil, fmt.Errorf("unknown maze format starting with %q", lines[0][0])
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b, b.Validate()
+}
+
+// parsePlain parses a maze in the PrintMaze format. The first line is
+// the top wall, then each row of rooms takes a line: a | for the left
+// wall, and for each room two characters for the floor (the start or
+// the treasure followed by _ if there's a wall at the bottom) and one
+// for the right side (| if there's a wall).
+func parsePlain(lines [][]rune) (*Blueprint, error) {
+	top := lines[0]
+	if (len(top)-1)%3 != 0 || len(top) < 4 || strings.Trim(string(top), "_") != "" {
+		return nil, errors.New("line 1: invalid top wall")
+	}
+
+	w, h := (len(top)-1)/3, len(lines)-1
+	if h == 0 {
+		return nil, errors.New("maze has no rows")
+	}
+
+	b := newBlueprint(w, h)
+	start, treasure := false, false
+
+	for y := 0; y < h; y++ {
+		line := lines[y+1]
+		if len(line) != len(top) {
+			return nil, fmt.Errorf("line %d: %d characters long, expecting %d", y+2, len(line), len(top))
+		}
+		if line[0] != '|' {
+			return nil, fmt.Errorf("line %d: missing left wall", y+2)
+		}
+
+		for x := 0; x < w; x++ {
+			glyph, floor, side := line[1+3*x], line[2+3*x], line[3+3*x]
+			walls := &b.Walls[y][x]
+
+			switch floor {
+			case '_':
+				walls.Bottom = true
+			case ' ':
+			default:
+				return nil, fmt.Errorf("line %d: invalid floor %q", y+2, floor)
+			}
+
+			switch side {
+			case '|':
+				walls.Right = true
+			case '_':
+			default:
+				return nil, fmt.Errorf("line %d: invalid side %q", y+2, side)
+			}
+
+			switch glyph {
+			case '_', ' ':
+			case '⏀', '⏂', 'S':
+				if start {
+					return nil, fmt.Errorf("line %d: more than one start", y+2)
+				}
+				b.Start, start = Coordinate{x, y}, true
+			case '⏃', '⏅', 'T':
+				if treasure {
+					return nil, fmt.Errorf("line %d: more than one treasure", y+2)
+				}
+				b.Treasure, treasure = Coordinate{x, y}, true
+			default:
+				return nil, fmt.Errorf("line %d: invalid room %q", y+2, glyph)
+			}
+
+			// the walls shared with the rooms to the left and
+			// above were given with those
+			walls.Left = x == 0 || b.Walls[y][x-1].Right
+			walls.Top = y == 0 || b.Walls[y-1][x].Bottom
+		}
+
+		// the outer walls are always there
+		b.Walls[y][w-1].Right = true
+	}
+
+	for x := 0; x < w; x++ {
+		b.Walls[h-1][x].Bottom = true
+	}
+
+	if !start {
+		return nil, errors.New("maze has no start")
+	}
+	if !treasure {
+		return nil, errors.New("maze has no treasure")
+	}
+
+	return b, nil
+}
+
+// parsePretty parses a maze in the PrintPrettyMaze format, where each
+// room takes a block of three by three characters with the walls
+// around and what's in the room in the middle. Since Icarus wakes up
+// at the start, he marks it if the start isn't drawn. If the treasure
+// isn't drawn and Icarus is somewhere else, he's standing on it, as
+// after solving the maze.
+func parsePretty(lines [][]rune) (*Blueprint, error) {
+	if len(lines)%3 != 0 {
+		return nil, fmt.Errorf("%d lines, expecting a multiple of 3", len(lines))
+	}
+	if len(lines[0])%3 != 0 {
+		return nil, fmt.Errorf("line 1: %d characters long, expecting a multiple of 3", len(lines[0]))
+	}
+
+	w, h := len(lines[0])/3, len(lines)/3
+
+	b := newBlueprint(w, h)
+	start, treasure, icarus := false, false, false
+	var at Coordinate
+
+	for i, line := range lines {
+		if len(line) != 3*w {
+			return nil, fmt.Errorf("line %d: %d characters long, expecting %d", i+1, len(line), 3*w)
+		}
+	}
+
+	// wall returns true if c is the character for a wall, and an
+	// error if it's neither a wall nor an opening
+	wall := func(c, glyph rune, line int) (bool, error) {
+		switch c {
+		case glyph:
+			return true, nil
+		case ' ':
+			return false, nil
+		}
+		return false, fmt.Errorf("line %d: invalid wall %q", line, c)
+	}
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			top, mid, bottom := lines[3*y][3*x:3*x+3], lines[3*y+1][3*x:3*x+3], lines[3*y+2][3*x:3*x+3]
+
+			if top[0] != '▛' || top[2] != '▜' || bottom[0] != '▙' || bottom[2] != '▟' {
+				return nil, fmt.Errorf("line %d: invalid corners for room (%d, %d)", 3*y+1, x, y)
+			}
+
+			walls := &b.Walls[y][x]
+			var err error
+			if walls.Top, err = wall(top[1], '▀', 3*y+1); err != nil {
+				return nil, err
+			}
+			if walls.Left, err = wall(mid[0], '▌', 3*y+2); err != nil {
+				return nil, err
+			}
+			if walls.Right, err = wall(mid[2], '▐', 3*y+2); err != nil {
+				return nil, err
+			}
+			if walls.Bottom, err = wall(bottom[1], '▄', 3*y+3); err != nil {
+				return nil, err
+			}
+
+			switch mid[1] {
+			case ' ', '·':
+			case '⚑', 'S':
+				if start {
+					return nil, fmt.Errorf("line %d: more than one start", 3*y+2)
+				}
+				b.Start, start = Coordinate{x, y}, true
+			case '×', 'T':
+				if treasure {
+					return nil, fmt.Errorf("line %d: more than one treasure", 3*y+2)
+				}
+				b.Treasure, treasure = Coordinate{x, y}, true
+			case '☉':
+				if icarus {
+					return nil, fmt.Errorf("line %d: more than one Icarus", 3*y+2)
+				}
+				at, icarus = Coordinate{x, y}, true
+			default:
+				return nil, fmt.Errorf("line %d: invalid room %q", 3*y+2, mid[1])
+			}
+		}
+	}
+
+	switch {
+	case !start && icarus:
+		b.Start, start = at, true
+	case !treasure && icarus && at != b.Start:
+		b.Treasure, treasure = at, true
+	}
+
+	if !start {
+		return nil, errors.New("maze has no start")
+	}
+	if !treasure {
+		return nil, errors.New("maze has no treasure")
+	}
+
+	return b, nil
+}
+
+// newBlueprint returns a blueprint for a maze of w×h rooms without
+// walls
+func newBlueprint(w, h int) *Blueprint {
+	b := &Blueprint{Width: w, Height: h, Walls: make([][]Survey, h)}
+	for y := range b.Walls {
+		b.Walls[y] = make([]Survey, w)
+	}
+	return b
+}
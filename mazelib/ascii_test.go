@@ -0,0 +1,128 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMazeRoundTrip(t *testing.T) {
+	writers := map[string]func(b *bytes.Buffer, m MazeI) error{
+		"plain":  func(b *bytes.Buffer, m MazeI) error { return WriteMaze(b, m) },
+		"pretty": func(b *bytes.Buffer, m MazeI) error { return WritePrettyMaze(b, m) },
+	}
+
+	for name, write := range writers {
+		for _, b := range testBlueprints(t) {
+			m := newTestMaze(b.Width, b.Height)
+			if err := b.Build(m); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := write(&buf, m); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := ParseMaze(&buf)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !reflect.DeepEqual(got, b) {
+				t.Errorf("%s: read %+v, expecting %+v", name, got, b)
+			}
+		}
+	}
+}
+
+func TestParseMazeByHand(t *testing.T) {
+	b, err := ParseMaze(strings.NewReader(`
+_____________
+|S |  ____T_|
+|_____|_____|
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Blueprint{
+		Width:  4,
+		Height: 2,
+		Walls: [][]Survey{
+			{{true, true, false, true}, {true, false, false, true}, {true, false, true, false}, {true, true, true, false}},
+			{{false, false, true, true}, {false, true, true, false}, {true, false, true, true}, {true, true, true, false}},
+		},
+		Start:    Coordinate{0, 0},
+		Treasure: Coordinate{3, 0},
+	}
+	if !reflect.DeepEqual(b, want) {
+		t.Errorf("read %+v, expecting %+v", b, want)
+	}
+}
+
+func TestParseMazeSolved(t *testing.T) {
+	for _, b := range testBlueprints(t) {
+		m := newTestMaze(b.Width, b.Height)
+		if err := b.Build(m); err != nil {
+			t.Fatal(err)
+		}
+
+		// Icarus stands on the treasure, hiding it, and the rooms
+		// he went through are marked
+		m.rooms[b.Start.Y][b.Start.X].Visited = true
+		m.icarus = b.Treasure
+
+		var buf bytes.Buffer
+		if err := WritePrettyMaze(&buf, m); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ParseMaze(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, b) {
+			t.Errorf("read %+v, expecting %+v", got, b)
+		}
+	}
+}
+
+func TestParseMazeInvalid(t *testing.T) {
+	invalid := map[string]string{
+		"empty":        "\n\n",
+		"format":       "|S_T_|\n",
+		"top":          "_____\n|S_T_|\n",
+		"short line":   "_______\n|S_T_|\n",
+		"left wall":    "_______\n S _T_|\n",
+		"room":         "_______\n|S _X_|\n",
+		"two starts":   "__________\n|S _S _T_|\n",
+		"no treasure":  "_______\n|S ___|\n",
+		"pretty lines": "▛▀▜▛▀▜\n▌S  T▐\n",
+		"corners":      "▛▀▜▛▀▜\n▌S  T▐\n▙▄▟▙▄▛\n",
+		"pretty wall":  "▛▀▜▛▀▜\n▌S  T▐\n▙▄▟▙#▟\n",
+		"icarus":       "▛▀▜▛▀▜\n▌☉  ☉▐\n▙▄▟▙▄▟\n",
+		"only icarus":  "▛▀▜▛▀▜\n▌☉   ▐\n▙▄▟▙▄▟\n",
+	}
+
+	for name, drawing := range invalid {
+		if _, err := ParseMaze(strings.NewReader(drawing)); err == nil {
+			t.Errorf("%s: invalid maze accepted", name)
+		}
+	}
+}
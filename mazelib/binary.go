@@ -0,0 +1,428 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// The binary maze format is meant for mazes too large for JSON. It
+// starts with a header, all numbers little endian:
+//
+//	magic     4 bytes, "LMAZ"
+//	version   uint16
+//	width     uint32
+//	height    uint32
+//	start     uint32 x, uint32 y
+//	treasure  uint32 x, uint32 y
+//	checksum  uint32, CRC-32 (IEEE) of the rows that follow
+//
+// followed by one record per row of rooms, from top to bottom. Each
+// record holds two bits per room, from left to right: the east wall
+// and then the south wall, packed starting at the least significant
+// bit and padded to a whole byte. The other walls are the east and
+// south walls of the neighbors, and the outer walls of the maze are
+// always there.
+
+// BinaryVersion is the version of the binary maze format
+const BinaryVersion = 1
+
+// binaryMagic identifies the binary maze format
+var binaryMagic = [4]byte{'L', 'M', 'A', 'Z'}
+
+// maxBinarySize is the maximum width or height accepted by the binary
+// maze format, which keeps broken files from using up all the memory
+const maxBinarySize = 1 << 20
+
+// ErrChecksum is returned when the rooms of a maze don't match the
+// checksum in its header
+var ErrChecksum = errors.New("maze checksum mismatch")
+
+// BinaryHeader is the header of a maze in the binary format
+type BinaryHeader struct {
+	Width, Height   int
+	Start, Treasure Coordinate
+	Checksum        uint32
+}
+
+// binaryHeader is the header as laid out in the file
+type binaryHeader struct {
+	Magic     [4]byte
+	Version   uint16
+	Width     uint32
+	Height    uint32
+	StartX    uint32
+	StartY    uint32
+	TreasureX uint32
+	TreasureY uint32
+	Checksum  uint32
+}
+
+// validate checks that the header describes a maze that can be played
+func (h BinaryHeader) validate() error {
+	if h.Width <= 0 || h.Height <= 0 || h.Width > maxBinarySize || h.Height > maxBinarySize {
+		return fmt.Errorf("invalid maze size %dx%d", h.Width, h.Height)
+	}
+	if !Valid(h.Start.X, h.Start.Y, h.Width, h.Height) {
+		return fmt.Errorf("start (%d, %d) is outside of the maze", h.Start.X, h.Start.Y)
+	}
+	if !Valid(h.Treasure.X, h.Treasure.Y, h.Width, h.Height) {
+		return fmt.Errorf("treasure (%d, %d) is outside of the maze", h.Treasure.X, h.Treasure.Y)
+	}
+	if h.Start == h.Treasure {
+		return errors.New("start and treasure are in the same room")
+	}
+	return nil
+}
+
+// rowPacker packs rows of rooms, checking that their walls fit the
+// binary format
+type rowPacker struct {
+	width, height int
+	y             int
+	south         []bool // south walls of the previous row
+	buf           []byte
+}
+
+func newRowPacker(width, height int) *rowPacker {
+	return &rowPacker{
+		width:  width,
+		height: height,
+		south:  make([]bool, width),
+		buf:    make([]byte, (2*width+7)/8),
+	}
+}
+
+// pack returns the record for the next row
+func (p *rowPacker) pack(row []Survey) ([]byte, error) {
+	if p.y >= p.height {
+		return nil, errors.New("too many rows")
+	}
+	if len(row) != p.width {
+		return nil, fmt.Errorf("row %d has %d rooms, expecting %d", p.y, len(row), p.width)
+	}
+
+	for i := range p.buf {
+		p.buf[i] = 0
+	}
+
+	for x, s := range row {
+		west := x == 0 || row[x-1].Right
+		north := p.y == 0 || p.south[x]
+		east := s.Right || x == p.width-1
+		south := s.Bottom || p.y == p.height-1
+
+		if s.Left != west || s.Top != north || s.Right != east || s.Bottom != south {
+			return nil, fmt.Errorf("room (%d, %d) doesn't agree with its neighbors or lacks an outer wall", x, p.y)
+		}
+
+		if east {
+			p.buf[(2*x)/8] |= 1 << uint((2*x)%8)
+		}
+		if south {
+			p.buf[(2*x+1)/8] |= 1 << uint((2*x+1)%8)
+		}
+		p.south[x] = south
+	}
+
+	p.y++
+	return p.buf, nil
+}
+
+// BinaryChecksum returns the checksum of a maze of the given size for
+// the header of the binary format. row is called with each row number,
+// from top to bottom, and must return the walls of the rooms in it.
+func BinaryChecksum(width, height int, row func(y int) ([]Survey, error)) (uint32, error) {
+	p := newRowPacker(width, height)
+	h := crc32.NewIEEE()
+
+	for y := 0; y < height; y++ {
+		r, err := row(y)
+		if err != nil {
+			return 0, err
+		}
+		b, err := p.pack(r)
+		if err != nil {
+			return 0, err
+		}
+		h.Write(b)
+	}
+
+	return h.Sum32(), nil
+}
+
+// BinaryWriter writes a maze in the binary format one row at a time,
+// so mazes don't have to be kept in memory as a whole
+type BinaryWriter struct {
+	w      *bufio.Writer
+	h      BinaryHeader
+	packer *rowPacker
+	crc    hash.Hash32
+}
+
+// NewBinaryWriter writes header h to w and returns a writer for the
+// rows of the maze. The checksum in the header must be the one returned
+// by BinaryChecksum for the rows that will be written.
+func NewBinaryWriter(w io.Writer, h BinaryHeader) (*BinaryWriter, error) {
+	if err := h.validate(); err != nil {
+		return nil, err
+	}
+
+	bw := &BinaryWriter{
+		w:      bufio.NewWriter(w),
+		h:      h,
+		packer: newRowPacker(h.Width, h.Height),
+		crc:    crc32.NewIEEE(),
+	}
+
+	err := binary.Write(bw.w, binary.LittleEndian, binaryHeader{
+		Magic:     binaryMagic,
+		Version:   BinaryVersion,
+		Width:     uint32(h.Width),
+		Height:    uint32(h.Height),
+		StartX:    uint32(h.Start.X),
+		StartY:    uint32(h.Start.Y),
+		TreasureX: uint32(h.Treasure.X),
+		TreasureY: uint32(h.Treasure.Y),
+		Checksum:  h.Checksum,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return bw, nil
+}
+
+// WriteRow writes the walls of the next row of rooms
+func (bw *BinaryWriter) WriteRow(row []Survey) error {
+	b, err := bw.packer.pack(row)
+	if err != nil {
+		return err
+	}
+
+	bw.crc.Write(b)
+	_, err = bw.w.Write(b)
+	return err
+}
+
+// Close checks that all the rows were written and match the checksum
+// in the header, and flushes the output. It doesn't close the
+// underlying writer.
+func (bw *BinaryWriter) Close() error {
+	if bw.packer.y != bw.h.Height {
+		return fmt.Errorf("%d rows written, expecting %d", bw.packer.y, bw.h.Height)
+	}
+
+	if bw.crc.Sum32() != bw.h.Checksum {
+		return ErrChecksum
+	}
+
+	return bw.w.Flush()
+}
+
+// BinaryReader reads a maze in the binary format one row at a time
+type BinaryReader struct {
+	r     *bufio.Reader
+	h     BinaryHeader
+	y     int
+	south []bool // south walls of the previous row
+	buf   []byte
+	crc   hash.Hash32
+}
+
+// NewBinaryReader reads the header of a maze in the binary format from
+// r and returns a reader for its rows
+func NewBinaryReader(r io.Reader) (*BinaryReader, error) {
+	br := &BinaryReader{r: bufio.NewReader(r), crc: crc32.NewIEEE()}
+
+	var bh binaryHeader
+	if err := binary.Read(br.r, binary.LittleEndian, &bh); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	if bh.Magic != binaryMagic {
+		return nil, errors.New("not a maze: bad magic number")
+	}
+
+	if bh.Version < 1 || bh.Version > BinaryVersion {
+		return nil, fmt.Errorf("unsupported maze format version %d", bh.Version)
+	}
+
+	if bh.Width > maxBinarySize || bh.Height > maxBinarySize {
+		return nil, fmt.Errorf("invalid maze size %dx%d", bh.Width, bh.Height)
+	}
+
+	br.h = BinaryHeader{
+		Width:    int(bh.Width),
+		Height:   int(bh.Height),
+		Start:    Coordinate{int(bh.StartX), int(bh.StartY)},
+		Treasure: Coordinate{int(bh.TreasureX), int(bh.TreasureY)},
+		Checksum: bh.Checksum,
+	}
+
+	if err := br.h.validate(); err != nil {
+		return nil, err
+	}
+
+	br.south = make([]bool, br.h.Width)
+	br.buf = make([]byte, (2*br.h.Width+7)/8)
+
+	return br, nil
+}
+
+// Header returns the header of the maze
+func (br *BinaryReader) Header() BinaryHeader {
+	return br.h
+}
+
+// ReadRow reads the walls of the next row of rooms into row, which
+// must have room for the whole width of the maze. It returns io.EOF
+// once all the rows have been read, and ErrChecksum if they don't
+// match the checksum in the header.
+func (br *BinaryReader) ReadRow(row []Survey) error {
+	if br.y >= br.h.Height {
+		return io.EOF
+	}
+
+	if len(row) < br.h.Width {
+		return fmt.Errorf("row has room for %d rooms, expecting %d", len(row), br.h.Width)
+	}
+
+	if _, err := io.ReadFull(br.r, br.buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	br.crc.Write(br.buf)
+
+	for x := 0; x < br.h.Width; x++ {
+		s := Survey{
+			Right:  br.buf[(2*x)/8]&(1<<uint((2*x)%8)) != 0,
+			Bottom: br.buf[(2*x+1)/8]&(1<<uint((2*x+1)%8)) != 0,
+			Top:    br.y == 0 || br.south[x],
+			Left:   x == 0 || row[x-1].Right,
+		}
+		// the outer walls are always there
+		if x == br.h.Width-1 {
+			s.Right = true
+		}
+		if br.y == br.h.Height-1 {
+			s.Bottom = true
+		}
+		row[x] = s
+		br.south[x] = s.Bottom
+	}
+
+	br.y++
+
+	if br.y == br.h.Height && br.crc.Sum32() != br.h.Checksum {
+		return ErrChecksum
+	}
+
+	return nil
+}
+
+// WriteBinary writes maze m to w in the binary format. The rooms are
+// read from m twice, once to compute the checksum and once to write
+// them, so the maze is never copied as a whole.
+func WriteBinary(w io.Writer, m MazeI) error {
+	h := BinaryHeader{Width: m.Width(), Height: m.Height()}
+
+	start, treasure := false, false
+	row := make([]Survey, h.Width)
+	readRow := func(y int) ([]Survey, error) {
+		for x := range row {
+			r, err := m.GetRoom(x, y)
+			if err != nil {
+				return nil, err
+			}
+			row[x] = r.Walls
+			if r.Start {
+				h.Start, start = Coordinate{x, y}, true
+			}
+			if r.Treasure {
+				h.Treasure, treasure = Coordinate{x, y}, true
+			}
+		}
+		return row, nil
+	}
+
+	sum, err := BinaryChecksum(h.Width, h.Height, readRow)
+	if err != nil {
+		return err
+	}
+	h.Checksum = sum
+
+	if !start {
+		return errors.New("maze has no start")
+	}
+	if !treasure {
+		return errors.New("maze has no treasure")
+	}
+
+	bw, err := NewBinaryWriter(w, h)
+	if err != nil {
+		return err
+	}
+
+	for y := 0; y < h.Height; y++ {
+		if _, err := readRow(y); err != nil {
+			return err
+		}
+		if err := bw.WriteRow(row); err != nil {
+			return err
+		}
+	}
+
+	return bw.Close()
+}
+
+// ReadBinary reads a maze in the binary format from r. Use
+// Blueprint.Build to turn it into a playable maze.
+func ReadBinary(r io.Reader) (*Blueprint, error) {
+	br, err := NewBinaryReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	h := br.Header()
+	b := &Blueprint{
+		Width:    h.Width,
+		Height:   h.Height,
+		Walls:    make([][]Survey, h.Height),
+		Start:    h.Start,
+		Treasure: h.Treasure,
+	}
+
+	for y := range b.Walls {
+		b.Walls[y] = make([]Survey, h.Width)
+		if err := br.ReadRow(b.Walls[y]); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
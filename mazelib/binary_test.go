@@ -0,0 +1,197 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// binaryHeaderSize is the size of the header of the binary format, and
+// binaryChecksumAt where the checksum is in it
+const (
+	binaryHeaderSize = 34
+	binaryChecksumAt = 30
+)
+
+// writeBinary returns blueprint b in the binary format
+func writeBinary(t *testing.T, b *Blueprint) []byte {
+	m := newTestMaze(b.Width, b.Height)
+	if err := b.Build(m); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBinary(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	for _, b := range testBlueprints(t) {
+		data := writeBinary(t, b)
+
+		if n := binaryHeaderSize + b.Height*((2*b.Width+7)/8); len(data) != n {
+			t.Errorf("%dx%d maze takes %d bytes, expecting %d", b.Width, b.Height, len(data), n)
+		}
+
+		got, err := ReadBinary(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, b) {
+			t.Errorf("read %+v, expecting %+v", got, b)
+		}
+	}
+}
+
+func TestBinaryRows(t *testing.T) {
+	for _, b := range testBlueprints(t) {
+		sum, err := BinaryChecksum(b.Width, b.Height, func(y int) ([]Survey, error) {
+			return b.Walls[y], nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		bw, err := NewBinaryWriter(&buf, BinaryHeader{b.Width, b.Height, b.Start, b.Treasure, sum})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range b.Walls {
+			if err := bw.WriteRow(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := bw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		br, err := NewBinaryReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		row := make([]Survey, b.Width)
+		for y := 0; y < b.Height; y++ {
+			if err := br.ReadRow(row); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(row, b.Walls[y]) {
+				t.Errorf("read row %d as %+v, expecting %+v", y, row, b.Walls[y])
+			}
+		}
+		if err := br.ReadRow(row); err != io.EOF {
+			t.Errorf("read past the last row: %v", err)
+		}
+	}
+}
+
+func TestBinaryWriterChecks(t *testing.T) {
+	bs := testBlueprints(t)
+	b := bs[len(bs)-1]
+	h := BinaryHeader{b.Width, b.Height, b.Start, b.Treasure, 0}
+
+	bw, err := NewBinaryWriter(io.Discard, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range b.Walls {
+		if err := bw.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bw.Close(); err != ErrChecksum {
+		t.Errorf("wrong checksum accepted: %v", err)
+	}
+
+	bw, _ = NewBinaryWriter(io.Discard, h)
+	if err := bw.WriteRow(b.Walls[0]); err != nil {
+		t.Fatal(err)
+	}
+	if err := bw.Close(); err == nil {
+		t.Error("missing rows accepted")
+	}
+
+	bw, _ = NewBinaryWriter(io.Discard, h)
+	row := append([]Survey(nil), b.Walls[0]...)
+	row[0].Top = false
+	if err := bw.WriteRow(row); err == nil {
+		t.Error("missing outer wall accepted")
+	}
+}
+
+func TestBinaryChecksum(t *testing.T) {
+	for _, b := range testBlueprints(t) {
+		data := writeBinary(t, b)
+
+		// every byte of the rooms and of the checksum itself
+		for i := binaryChecksumAt; i < len(data); i++ {
+			if i >= binaryChecksumAt+4 && i < binaryHeaderSize {
+				continue
+			}
+
+			broken := append([]byte(nil), data...)
+			broken[i] ^= 0x10
+
+			if _, err := ReadBinary(bytes.NewReader(broken)); err != ErrChecksum {
+				t.Errorf("%dx%d maze with byte %d flipped: got %v, expecting %v", b.Width, b.Height, i, err, ErrChecksum)
+			}
+		}
+	}
+}
+
+func TestBinaryTruncated(t *testing.T) {
+	for _, b := range testBlueprints(t) {
+		data := writeBinary(t, b)
+
+		for n := 0; n < len(data); n++ {
+			if _, err := ReadBinary(bytes.NewReader(data[:n])); err == nil {
+				t.Errorf("%dx%d maze truncated to %d bytes accepted", b.Width, b.Height, n)
+			}
+		}
+	}
+}
+
+func TestBinaryHeader(t *testing.T) {
+	data := writeBinary(t, testBlueprints(t)[0])
+
+	broken := map[string]func(data []byte){
+		"magic":    func(data []byte) { data[0] = 'M' },
+		"version":  func(data []byte) { data[4] = BinaryVersion + 1 },
+		"width":    func(data []byte) { copy(data[6:10], []byte{0, 0, 0, 0}) },
+		"height":   func(data []byte) { copy(data[10:14], []byte{0, 0, 0, 0x10}) },
+		"start":    func(data []byte) { data[14] = 0xff },
+		"treasure": func(data []byte) { data[26] = 0xff },
+	}
+
+	for name, breakIt := range broken {
+		d := append([]byte(nil), data...)
+		breakIt(d)
+
+		if _, err := ReadBinary(bytes.NewReader(d)); err == nil {
+			t.Errorf("%s: broken header accepted", name)
+		}
+	}
+
+	if _, err := ReadMaze(bytes.NewReader(append([]byte("LMAX"), data[4:]...))); err == nil {
+		t.Error("bad magic number accepted")
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Info describes where a maze came from. All of it is optional.
+type Info struct {
+	// Generator is the name of the algorithm that created the maze
+	Generator string
+
+	// Seed is the seed used by the generator
+	Seed int64
+
+	// Metadata holds anything else worth keeping with the maze
+	Metadata map[string]string
+}
+
+// Blueprint describes a maze independently of the MazeI implementation
+// used to play it. It's what the maze formats save and load.
+type Blueprint struct {
+	Width, Height int
+
+	// Walls holds the walls of each room, one row at a time
+	Walls [][]Survey
+
+	Start, Treasure Coordinate
+
+	Info
+}
+
+// NewBlueprint returns the blueprint of maze m
+func NewBlueprint(m MazeI, info Info) (*Blueprint, error) {
+	b := &Blueprint{
+		Width:  m.Width(),
+		Height: m.Height(),
+		Walls:  make([][]Survey, m.Height()),
+		Info:   info,
+	}
+
+	start, treasure := false, false
+
+	for y := range b.Walls {
+		b.Walls[y] = make([]Survey, b.Width)
+		for x := range b.Walls[y] {
+			r, err := m.GetRoom(x, y)
+			if err != nil {
+				return nil, err
+			}
+			b.Walls[y][x] = r.Walls
+			if r.Start {
+				b.Start, start = Coordinate{x, y}, true
+			}
+			if r.Treasure {
+				b.Treasure, treasure = Coordinate{x, y}, true
+			}
+		}
+	}
+
+	if !start {
+		return nil, errors.New("maze has no start")
+	}
+	if !treasure {
+		return nil, errors.New("maze has no treasure")
+	}
+
+	return b, b.Validate()
+}
+
+// Validate checks that the blueprint describes a maze that can be
+// played: the walls cover all the rooms, the rooms on both sides of a
+// wall agree on it being there, and the start and the treasure are in
+// different rooms inside the maze.
+func (b *Blueprint) Validate() error {
+	if b.Width <= 0 || b.Height <= 0 {
+		return fmt.Errorf("invalid maze size %dx%d", b.Width, b.Height)
+	}
+
+	if len(b.Walls) != b.Height {
+		return fmt.Errorf("walls given for %d rows, expecting %d", len(b.Walls), b.Height)
+	}
+
+	for y, row := range b.Walls {
+		if len(row) != b.Width {
+			return fmt.Errorf("walls given for %d rooms in row %d, expecting %d", len(row), y, b.Width)
+		}
+	}
+
+	for y := 0; y < b.Height; y++ {
+		for x := 0; x < b.Width; x++ {
+			if x+1 < b.Width && b.Walls[y][x].Right != b.Walls[y][x+1].Left {
+				return fmt.Errorf("rooms (%d, %d) and (%d, %d) disagree about the wall between them", x, y, x+1, y)
+			}
+			if y+1 < b.Height && b.Walls[y][x].Bottom != b.Walls[y+1][x].Top {
+				return fmt.Errorf("rooms (%d, %d) and (%d, %d) disagree about the wall between them", x, y, x, y+1)
+			}
+		}
+	}
+
+	if !Valid(b.Start.X, b.Start.Y, b.Width, b.Height) {
+		return fmt.Errorf("start (%d, %d) is outside of the maze", b.Start.X, b.Start.Y)
+	}
+
+	if !Valid(b.Treasure.X, b.Treasure.Y, b.Width, b.Height) {
+		return fmt.Errorf("treasure (%d, %d) is outside of the maze", b.Treasure.X, b.Treasure.Y)
+	}
+
+	if b.Start == b.Treasure {
+		return errors.New("start and treasure are in the same room")
+	}
+
+	return nil
+}
+
+// Build copies the blueprint into maze m, which must have the same
+// size. Icarus is placed at the start.
+func (b *Blueprint) Build(m MazeI) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+
+	if m.Width() != b.Width || m.Height() != b.Height {
+		return fmt.Errorf("maze is %dx%d, expecting %dx%d", m.Width(), m.Height(), b.Width, b.Height)
+	}
+
+	for y, row := range b.Walls {
+		for x, walls := range row {
+			r, err := m.GetRoom(x, y)
+			if err != nil {
+				return err
+			}
+			r.Walls = walls
+		}
+	}
+
+	if err := m.SetStartPoint(b.Start.X, b.Start.Y); err != nil {
+		return err
+	}
+
+	return m.SetTreasure(b.Treasure.X, b.Treasure.Y)
+}
+
+// ReadMaze reads a maze in any of the formats known to this package:
+// JSON, binary or one of the drawings parsed by ParseMaze, telling them
+// apart by the first bytes
+func ReadMaze(r io.Reader) (*Blueprint, error) {
+	br := bufio.NewReader(r)
+
+	// enough to skip some whitespace before a JSON document
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(head, binaryMagic[:]):
+		return ReadBinary(br)
+
+	case bytes.HasPrefix(bytes.TrimLeft(head, " \t\r\n"), []byte("{")):
+		data, err := io.ReadAll(br)
+		if err != nil {
+			return nil, err
+		}
+		return Unmarshal(data)
+	}
+
+	return ParseMaze(br)
+}
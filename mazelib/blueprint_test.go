@@ -0,0 +1,193 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// testMaze is a minimal MazeI, enough to save and build mazes
+type testMaze struct {
+	rooms  [][]Room
+	icarus Coordinate
+}
+
+func newTestMaze(w, h int) *testMaze {
+	m := &testMaze{rooms: make([][]Room, h)}
+	for y := range m.rooms {
+		m.rooms[y] = make([]Room, w)
+	}
+	return m
+}
+
+func (m *testMaze) GetRoom(x, y int) (*Room, error) {
+	if !Valid(x, y, m.Width(), m.Height()) {
+		return nil, ErrOutOfBounds
+	}
+	return &m.rooms[y][x], nil
+}
+
+func (m *testMaze) Width() int  { return len(m.rooms[0]) }
+func (m *testMaze) Height() int { return len(m.rooms) }
+
+func (m *testMaze) SetStartPoint(x, y int) error {
+	r, err := m.GetRoom(x, y)
+	if err != nil {
+		return err
+	}
+	r.Start = true
+	m.icarus = Coordinate{x, y}
+	return nil
+}
+
+func (m *testMaze) SetTreasure(x, y int) error {
+	r, err := m.GetRoom(x, y)
+	if err != nil {
+		return err
+	}
+	r.Treasure = true
+	return nil
+}
+
+func (m *testMaze) LookAround() (Survey, error) {
+	return m.Discover(m.icarus.X, m.icarus.Y)
+}
+
+func (m *testMaze) Discover(x, y int) (Survey, error) {
+	r, err := m.GetRoom(x, y)
+	if err != nil {
+		return Survey{}, err
+	}
+	return r.Walls, nil
+}
+
+func (m *testMaze) Icarus() (int, int) { return m.icarus.X, m.icarus.Y }
+
+func (m *testMaze) move(dir int) error {
+	s, err := m.LookAround()
+	if err != nil {
+		return err
+	}
+	if s.Wall(dir) {
+		return ErrWall
+	}
+	m.icarus.X, m.icarus.Y = Shift(m.icarus.X, m.icarus.Y, dir)
+	return nil
+}
+
+func (m *testMaze) MoveLeft() error  { return m.move(W) }
+func (m *testMaze) MoveRight() error { return m.move(E) }
+func (m *testMaze) MoveUp() error    { return m.move(N) }
+func (m *testMaze) MoveDown() error  { return m.move(S) }
+
+// randomMaze returns a w×h maze where each inner wall is there or not
+// at random, with the start and the treasure in random rooms
+func randomMaze(r *rand.Rand, w, h int) *testMaze {
+	m := newTestMaze(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			room := &m.rooms[y][x]
+			room.Walls.Left = x == 0 || room.Walls.Left
+			room.Walls.Top = y == 0 || room.Walls.Top
+			room.Walls.Right = x == w-1
+			room.Walls.Bottom = y == h-1
+			if x < w-1 && r.Intn(2) == 0 {
+				AddWall(m, x, y, E)
+			}
+			if y < h-1 && r.Intn(2) == 0 {
+				AddWall(m, x, y, S)
+			}
+		}
+	}
+
+	sx, sy := r.Intn(w), r.Intn(h)
+	tx, ty := sx, sy
+	for tx == sx && ty == sy {
+		tx, ty = r.Intn(w), r.Intn(h)
+	}
+	m.SetStartPoint(sx, sy)
+	m.SetTreasure(tx, ty)
+
+	return m
+}
+
+// testSizes are the sizes of the mazes used by the tests
+var testSizes = [][2]int{{1, 2}, {2, 1}, {2, 2}, {7, 4}, {16, 9}, {9, 16}}
+
+// testBlueprints returns the blueprints of a few random mazes of each
+// of the test sizes
+func testBlueprints(t *testing.T) []*Blueprint {
+	r := rand.New(rand.NewSource(1))
+
+	var bs []*Blueprint
+	for _, size := range testSizes {
+		for i := 0; i < 5; i++ {
+			b, err := NewBlueprint(randomMaze(r, size[0], size[1]), Info{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			bs = append(bs, b)
+		}
+	}
+
+	return bs
+}
+
+func TestBlueprintBuild(t *testing.T) {
+	for _, b := range testBlueprints(t) {
+		m := newTestMaze(b.Width, b.Height)
+		if err := b.Build(m); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := NewBlueprint(m, b.Info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, b) {
+			t.Errorf("built %+v, expecting %+v", got, b)
+		}
+	}
+}
+
+func TestBlueprintValidate(t *testing.T) {
+	b := testBlueprints(t)[len(testSizes)*5-1]
+
+	broken := map[string]func(b *Blueprint){
+		"size":      func(b *Blueprint) { b.Width = 0 },
+		"rows":      func(b *Blueprint) { b.Walls = b.Walls[1:] },
+		"rooms":     func(b *Blueprint) { b.Walls[2] = b.Walls[2][1:] },
+		"walls":     func(b *Blueprint) { b.Walls[2][3].Right = !b.Walls[2][4].Left },
+		"start":     func(b *Blueprint) { b.Start.X = b.Width },
+		"treasure":  func(b *Blueprint) { b.Treasure.Y = -1 },
+		"same room": func(b *Blueprint) { b.Treasure = b.Start },
+	}
+
+	for name, breakIt := range broken {
+		c := *b
+		c.Walls = make([][]Survey, len(b.Walls))
+		for y, row := range b.Walls {
+			c.Walls[y] = append([]Survey(nil), row...)
+		}
+		breakIt(&c)
+
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: broken blueprint is valid", name)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"time"
+)
+
+// maxGIFFrames is the largest number of frames in the animations made by
+// WriteGIF. Longer trajectories take several moves per frame.
+const maxGIFFrames = 500
+
+// heatLevels is the number of shades used for the heatmap
+const heatLevels = 8
+
+// RenderImage draws maze m as WriteSVG does, returning the image
+func RenderImage(m MazeI, o RenderOptions) (*image.RGBA, error) {
+	r, err := newRasterizer(m, o)
+	if err != nil {
+		return nil, err
+	}
+
+	img := image.NewRGBA(r.bounds())
+	r.drawMaze(img, r.o.Heatmap)
+
+	if len(r.o.Trajectory) > 0 {
+		r.drawPath(img, walk(r.b.Start, r.o.Trajectory), r.c.Trajectory, r.cell/8)
+	}
+
+	r.drawWalls(img)
+	r.drawObjects(img)
+
+	return img, nil
+}
+
+// WritePNG writes a PNG image of maze m to w, drawn as RenderImage does
+func WritePNG(w io.Writer, m MazeI, o RenderOptions) error {
+	img, err := RenderImage(m, o)
+	if err != nil {
+		return err
+	}
+
+	return png.Encode(w, img)
+}
+
+// WriteGIF writes an animated GIF of Icarus following o.Trajectory in
+// maze m to w, showing each move for delay. The last frame stays on
+// screen for a while longer. The heatmap isn't drawn.
+func WriteGIF(w io.Writer, m MazeI, o RenderOptions, delay time.Duration) error {
+	r, err := newRasterizer(m, o)
+	if err != nil {
+		return err
+	}
+
+	// there are no frames shorter than 1/100 s
+	hundredths := int(delay / (10 * time.Millisecond))
+	if hundredths < 1 {
+		hundredths = 1
+	}
+
+	rooms := walk(r.b.Start, r.o.Trajectory)
+	moves := (len(rooms) + maxGIFFrames - 1) / maxGIFFrames
+
+	// the trail is drawn on canvas a move at a time, and each frame
+	// is a copy with Icarus on top. The trail goes through the middle
+	// of the rooms, so it never covers the walls, which can be drawn
+	// just once.
+	canvas := image.NewPaletted(r.bounds(), r.palette())
+	r.drawMaze(canvas, false)
+	r.drawWalls(canvas)
+
+	anim := &gif.GIF{}
+	frame := func(n int) {
+		img := image.NewPaletted(canvas.Rect, canvas.Palette)
+		copy(img.Pix, canvas.Pix)
+		r.drawObjects(img)
+		r.drawCircle(img, rooms[n], r.cell*3/8, r.c.Trajectory)
+
+		anim.Image = append(anim.Image, img)
+		anim.Delay = append(anim.Delay, hundredths*moves)
+	}
+
+	frame(0)
+	for i := 1; i < len(rooms); i++ {
+		r.drawPath(canvas, rooms[i-1:i+1], r.c.Trajectory, r.cell/8)
+		if i%moves == 0 || i == len(rooms)-1 {
+			frame(i)
+		}
+	}
+
+	anim.Delay[len(anim.Delay)-1] = 200
+
+	return gif.EncodeAll(w, anim)
+}
+
+// rasterizer draws mazes on images
+type rasterizer struct {
+	m MazeI
+	b *Blueprint
+	o RenderOptions
+	c colors
+
+	// cell is the size of each room and stroke the width of the
+	// walls, in pixels
+	cell, stroke int
+}
+
+// newRasterizer returns a rasterizer for maze m, using options o
+func newRasterizer(m MazeI, o RenderOptions) (*rasterizer, error) {
+	b, err := NewBlueprint(m, Info{})
+	if err != nil {
+		return nil, err
+	}
+
+	o = o.withDefaults()
+
+	stroke := o.CellSize / 10
+	if stroke < 1 {
+		stroke = 1
+	}
+
+	return &rasterizer{m: m, b: b, o: o, c: newColors(o.Palette), cell: o.CellSize, stroke: stroke}, nil
+}
+
+// bounds returns the size of the images
+func (r *rasterizer) bounds() image.Rectangle {
+	return image.Rect(0, 0, 2*r.stroke+r.b.Width*r.cell, 2*r.stroke+r.b.Height*r.cell)
+}
+
+// corner returns the position of the top left corner of room (x, y)
+func (r *rasterizer) corner(x, y int) image.Point {
+	return image.Pt(r.stroke+x*r.cell, r.stroke+y*r.cell)
+}
+
+// center returns the position of the center of room c
+func (r *rasterizer) center(c Coordinate) image.Point {
+	return r.corner(c.X, c.Y).Add(image.Pt(r.cell/2, r.cell/2))
+}
+
+// heat returns the color of a room visited n times, when the most
+// visited one was visited max times
+func (r *rasterizer) heat(n, max int) color.RGBA {
+	level := 1 + (heatLevels-1)*n/max
+	return blend(r.c.Background, r.c.Heat, float64(level)/heatLevels)
+}
+
+// palette returns the colors used by the rasterizer, for paletted
+// images
+func (r *rasterizer) palette() color.Palette {
+	c := r.c
+	p := color.Palette{c.Background, c.Wall, c.Start, c.Treasure, c.Optimal, c.Trajectory}
+	for level := 1; level <= heatLevels; level++ {
+		p = append(p, blend(c.Background, c.Heat, float64(level)/heatLevels))
+	}
+	return p
+}
+
+// drawMaze draws the background, the heatmap if heat is true, and the
+// optimal path if required
+func (r *rasterizer) drawMaze(img draw.Image, heat bool) {
+	fill(img, img.Bounds(), r.c.Background)
+
+	if heat && len(r.o.Trajectory) > 0 {
+		counts, max := visits(walk(r.b.Start, r.o.Trajectory), r.b.Width, r.b.Height)
+		for y, row := range counts {
+			for x, n := range row {
+				if n == 0 {
+					continue
+				}
+				p := r.corner(x, y)
+				fill(img, image.Rectangle{p, p.Add(image.Pt(r.cell, r.cell))}, r.heat(n, max))
+			}
+		}
+	}
+
+	if r.o.Optimal {
+		if path := ShortestPath(r.m, r.b.Start, r.b.Treasure); path != nil {
+			r.drawPath(img, walk(r.b.Start, path), r.c.Optimal, r.cell/4)
+		}
+	}
+}
+
+// drawPath draws a line of the given width going through the center of
+// rooms, each one next to the previous one
+func (r *rasterizer) drawPath(img draw.Image, rooms []Coordinate, c color.RGBA, width int) {
+	if width < 1 {
+		width = 1
+	}
+	half := image.Pt(width/2, width/2)
+
+	for i := 1; i < len(rooms); i++ {
+		a, b := r.center(rooms[i-1]), r.center(rooms[i])
+		rect := image.Rectangle{a, b}.Canon()
+		rect.Min = rect.Min.Sub(half)
+		rect.Max = rect.Max.Sub(half).Add(image.Pt(width, width))
+		fill(img, rect, c)
+	}
+}
+
+// drawWalls draws the walls of all the rooms. Like in WriteSVG, each
+// room draws its top and left walls, and the rooms along the right and
+// bottom edges draw those too.
+func (r *rasterizer) drawWalls(img draw.Image) {
+	c := r.c.Wall
+	half := image.Pt(r.stroke/2, r.stroke/2)
+	thick := image.Pt(r.stroke, r.stroke)
+
+	// wall draws a wall from p going dx, dy pixels to the right and
+	// down
+	wall := func(p image.Point, dx, dy int) {
+		p = p.Sub(half)
+		fill(img, image.Rectangle{p, p.Add(image.Pt(dx, dy)).Add(thick)}, c)
+	}
+
+	for y, row := range r.b.Walls {
+		for x, s := range row {
+			p := r.corner(x, y)
+			if s.Top {
+				wall(p, r.cell, 0)
+			}
+			if s.Left {
+				wall(p, 0, r.cell)
+			}
+			if s.Right && x == r.b.Width-1 {
+				wall(p.Add(image.Pt(r.cell, 0)), 0, r.cell)
+			}
+			if s.Bottom && y == r.b.Height-1 {
+				wall(p.Add(image.Pt(0, r.cell)), r.cell, 0)
+			}
+		}
+	}
+}
+
+// drawObjects draws the start as a circle and the treasure as a diamond
+func (r *rasterizer) drawObjects(img draw.Image) {
+	r.drawCircle(img, r.b.Start, r.cell/4, r.c.Start)
+
+	center, radius := r.center(r.b.Treasure), r.cell/3
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if abs(dx)+abs(dy) <= radius {
+				img.Set(center.X+dx, center.Y+dy, r.c.Treasure)
+			}
+		}
+	}
+}
+
+// drawCircle draws a circle of the given radius in the middle of room
+// at
+func (r *rasterizer) drawCircle(img draw.Image, at Coordinate, radius int, c color.RGBA) {
+	center := r.center(at)
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy <= radius*radius {
+				img.Set(center.X+dx, center.Y+dy, c)
+			}
+		}
+	}
+}
+
+// fill paints rectangle rect in img with color c
+func fill(img draw.Image, rect image.Rectangle, c color.RGBA) {
+	draw.Draw(img, rect, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+// blend returns the color that's t of the way from a to b
+func blend(a, b color.RGBA, t float64) color.RGBA {
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), mix(a.A, b.A)}
+}
+
+// abs returns the absolute value of x
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,132 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// pixel returns the color of the pixel at the center of room c, for
+// rooms of cell pixels and walls of stroke pixels
+func pixel(img image.Image, c Coordinate, cell, stroke int) color.RGBA {
+	x, y := stroke+c.X*cell+cell/2, stroke+c.Y*cell+cell/2
+	return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+}
+
+func TestWritePNG(t *testing.T) {
+	m := drawnMaze(t, corridor)
+
+	tests := []struct {
+		o      RenderOptions
+		stroke int
+	}{
+		{RenderOptions{CellSize: 10}, 1},
+		{RenderOptions{CellSize: 25, Optimal: true, Trajectory: []int{E, E}, Heatmap: true}, 2},
+		{RenderOptions{}, 2},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if err := WritePNG(&buf, m, test.o); err != nil {
+			t.Fatal(err)
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cell := test.o.CellSize
+		if cell == 0 {
+			cell = defaultCellSize
+		}
+		if got, want := img.Bounds(), image.Rect(0, 0, 2*test.stroke+3*cell, 2*test.stroke+cell); got != want {
+			t.Errorf("cell %d: bounds %v, expecting %v", cell, got, want)
+		}
+
+		// the start and the treasure are drawn on top of everything
+		if c := pixel(img, Coordinate{0, 0}, cell, test.stroke); c != *DefaultPalette.Start {
+			t.Errorf("cell %d: start is %v, expecting %v", cell, c, *DefaultPalette.Start)
+		}
+		if c := pixel(img, Coordinate{2, 0}, cell, test.stroke); c != *DefaultPalette.Treasure {
+			t.Errorf("cell %d: treasure is %v, expecting %v", cell, c, *DefaultPalette.Treasure)
+		}
+	}
+}
+
+func TestWriteGIF(t *testing.T) {
+	m := drawnMaze(t, corridor)
+
+	// back and forth along the corridor, ending at the treasure
+	long := make([]int, 0, 1200)
+	for len(long) < 1198 {
+		long = append(long, E, W)
+	}
+	long = append(long, E, E)
+
+	tests := []struct {
+		name       string
+		trajectory []int
+		delay      time.Duration
+		frames     int
+		moves      int
+	}{
+		{"empty", nil, 100 * time.Millisecond, 1, 1},
+		{"short", []int{E, E}, 50 * time.Millisecond, 3, 1},
+		{"too fast", []int{E, E}, time.Millisecond, 3, 1},
+		{"one frame per move", long[:maxGIFFrames-1], 20 * time.Millisecond, maxGIFFrames, 1},
+		{"long", long, 20 * time.Millisecond, 401, 3},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		o := RenderOptions{CellSize: 10, Trajectory: test.trajectory}
+		if err := WriteGIF(&buf, m, o, test.delay); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		anim, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if len(anim.Image) != test.frames || len(anim.Image) > maxGIFFrames {
+			t.Errorf("%s: %d frames, expecting %d", test.name, len(anim.Image), test.frames)
+		}
+
+		hundredths := int(test.delay / (10 * time.Millisecond))
+		if hundredths < 1 {
+			hundredths = 1
+		}
+		for i, d := range anim.Delay[:len(anim.Delay)-1] {
+			if d != hundredths*test.moves {
+				t.Errorf("%s: frame %d lasts %d, expecting %d", test.name, i, d, hundredths*test.moves)
+				break
+			}
+		}
+		if d := anim.Delay[len(anim.Delay)-1]; d != 200 {
+			t.Errorf("%s: last frame lasts %d, expecting 200", test.name, d)
+		}
+
+		if got, want := anim.Image[0].Bounds(), image.Rect(0, 0, 32, 12); got != want {
+			t.Errorf("%s: bounds %v, expecting %v", test.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// JSONVersion is the version of the JSON maze format written by
+// Marshal. Unmarshal reads this version and all the previous ones.
+const JSONVersion = 1
+
+// jsonFormat identifies JSON documents holding a maze
+const jsonFormat = "labyrinth-maze"
+
+// The bits used for each wall in the JSON format
+const (
+	wallTop = 1 << iota
+	wallRight
+	wallBottom
+	wallLeft
+)
+
+// jsonMaze is the JSON representation of a maze. The walls of each room
+// are stored as a bitmask, with 1 for the top wall, 2 for the right one,
+// 4 for the bottom one and 8 for the left one.
+type jsonMaze struct {
+	Format    string            `json:"format"`
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Walls     [][]int           `json:"walls"`
+	Start     Coordinate        `json:"start"`
+	Treasure  Coordinate        `json:"treasure"`
+	Generator string            `json:"generator,omitempty"`
+	Seed      int64             `json:"seed,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Marshal returns the JSON encoding of maze m, described by info
+func Marshal(m MazeI, info Info) ([]byte, error) {
+	b, err := NewBlueprint(m, info)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.MarshalJSON()
+}
+
+// Unmarshal decodes a maze encoded by Marshal. Use Blueprint.Build to
+// turn it into a playable maze.
+func Unmarshal(data []byte) (*Blueprint, error) {
+	b := &Blueprint{}
+	if err := b.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (b *Blueprint) MarshalJSON() ([]byte, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	j := jsonMaze{
+		Format:    jsonFormat,
+		Version:   JSONVersion,
+		Width:     b.Width,
+		Height:    b.Height,
+		Walls:     make([][]int, b.Height),
+		Start:     b.Start,
+		Treasure:  b.Treasure,
+		Generator: b.Generator,
+		Seed:      b.Seed,
+		Metadata:  b.Metadata,
+	}
+
+	for y, row := range b.Walls {
+		j.Walls[y] = make([]int, len(row))
+		for x, s := range row {
+			j.Walls[y][x] = wallBits(s)
+		}
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (b *Blueprint) UnmarshalJSON(data []byte) error {
+	var j jsonMaze
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	if j.Format != jsonFormat {
+		return fmt.Errorf("not a maze: format is %q", j.Format)
+	}
+
+	if j.Version < 1 || j.Version > JSONVersion {
+		return fmt.Errorf("unsupported maze format version %d", j.Version)
+	}
+
+	nb := Blueprint{
+		Width:    j.Width,
+		Height:   j.Height,
+		Walls:    make([][]Survey, len(j.Walls)),
+		Start:    j.Start,
+		Treasure: j.Treasure,
+		Info: Info{
+			Generator: j.Generator,
+			Seed:      j.Seed,
+			Metadata:  j.Metadata,
+		},
+	}
+
+	for y, row := range j.Walls {
+		nb.Walls[y] = make([]Survey, len(row))
+		for x, bits := range row {
+			if bits&^(wallTop|wallRight|wallBottom|wallLeft) != 0 {
+				return fmt.Errorf("invalid walls %d for room (%d, %d)", bits, x, y)
+			}
+			nb.Walls[y][x] = Survey{
+				Top:    bits&wallTop != 0,
+				Right:  bits&wallRight != 0,
+				Bottom: bits&wallBottom != 0,
+				Left:   bits&wallLeft != 0,
+			}
+		}
+	}
+
+	if err := nb.Validate(); err != nil {
+		return err
+	}
+
+	*b = nb
+	return nil
+}
+
+// wallBits returns the bitmask for the walls in s
+func wallBits(s Survey) int {
+	var bits int
+	if s.Top {
+		bits |= wallTop
+	}
+	if s.Right {
+		bits |= wallRight
+	}
+	if s.Bottom {
+		bits |= wallBottom
+	}
+	if s.Left {
+		bits |= wallLeft
+	}
+	return bits
+}
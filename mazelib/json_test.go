@@ -0,0 +1,72 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	info := Info{Generator: "random", Seed: 42, Metadata: map[string]string{"note": "tricky"}}
+
+	for _, b := range testBlueprints(t) {
+		m := newTestMaze(b.Width, b.Height)
+		if err := b.Build(m); err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := Marshal(m, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Unmarshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := *b
+		want.Info = info
+		if !reflect.DeepEqual(got, &want) {
+			t.Errorf("read %+v, expecting %+v", got, &want)
+		}
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	valid := `{"format":"labyrinth-maze","version":1,"width":2,"height":1,"walls":[[11,14]],"start":{"x":0,"y":0},"treasure":{"x":1,"y":0}}`
+	if _, err := Unmarshal([]byte(valid)); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]string{
+		"format":   strings.Replace(valid, "labyrinth-maze", "labyrinth-map", 1),
+		"version":  strings.Replace(valid, `"version":1`, `"version":2`, 1),
+		"walls":    strings.Replace(valid, "[[11,14]]", "[[11,30]]", 1),
+		"disagree": strings.Replace(valid, "[[11,14]]", "[[9,14]]", 1),
+		"size":     strings.Replace(valid, `"width":2`, `"width":3`, 1),
+		"treasure": strings.Replace(valid, `"treasure":{"x":1`, `"treasure":{"x":2`, 1),
+		"json":     valid[:len(valid)-1],
+	}
+
+	for name, data := range invalid {
+		if _, err := Unmarshal([]byte(data)); err == nil {
+			t.Errorf("%s: invalid maze accepted", name)
+		}
+	}
+}
@@ -22,6 +22,7 @@ package mazelib
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
@@ -40,6 +41,25 @@ type Reply struct {
 	Error   bool   `json:"error"`
 }
 
+// Request from a client to the server, used when talking over a
+// websocket connection. Action is one of "awake", "move", "moves" or
+// "done". Direction is only used by "move" and Path is only used by
+// "moves".
+type Request struct {
+	Action    string   `json:"action"`
+	Direction string   `json:"direction,omitempty"`
+	Path      []string `json:"path,omitempty"`
+}
+
+// BatchReply from the server to a request to move several steps at
+// once. Replies holds the reply to each move attempted, and Taken
+// the number of moves that were actually made. The server stops at the
+// first move that fails, or once the treasure is found.
+type BatchReply struct {
+	Replies []Reply `json:"replies"`
+	Taken   int     `json:"taken"`
+}
+
 // Survey Given a location, survey surrounding locations
 // True indicates a wall is present.
 type Survey struct {
@@ -49,6 +69,22 @@ type Survey struct {
 	Left   bool `json:"left"`
 }
 
+// Wall returns true if there's a wall in direction dir
+func (s Survey) Wall(dir int) bool {
+	switch dir {
+	case N:
+		return s.Top
+	case S:
+		return s.Bottom
+	case E:
+		return s.Right
+	case W:
+		return s.Left
+	}
+
+	return true
+}
+
 const (
 	N = 1
 	S = 2
@@ -58,6 +94,25 @@ const (
 
 var ErrVictory error = errors.New("Victory")
 
+// ErrWall is returned when trying to walk through a wall
+var ErrWall error = errors.New("Can't walk through walls")
+
+// ErrOutOfBounds is returned when trying to access a room outside of
+// the maze
+var ErrOutOfBounds error = errors.New("room outside of maze boundaries")
+
+// ErrTooManyMoves is returned when a client moves faster than the
+// server allows
+var ErrTooManyMoves error = errors.New("too many moves, slow down")
+
+// ErrTooManySessions is returned when a client tries to play more mazes
+// at the same time than the server allows
+var ErrTooManySessions error = errors.New("too many sessions")
+
+// ErrSessionExpired is returned when moving in a maze that was
+// discarded because it wasn't played for too long
+var ErrSessionExpired error = errors.New("session expired")
+
 // Room contains the minimum informaion about a room in the maze.
 type Room struct {
 	Treasure bool
@@ -123,7 +178,15 @@ func AvgScores(in []int) int {
 
 // PrintMaze : Function to Print Maze to Console
 func PrintMaze(m MazeI) {
-	fmt.Println("_" + strings.Repeat("___", m.Width()))
+	if err := WriteMaze(os.Stdout, m); err != nil {
+		fmt.Println(err)
+		os.Exit(-1)
+	}
+}
+
+// WriteMaze writes the maze to w in the same format used by PrintMaze
+func WriteMaze(w io.Writer, m MazeI) error {
+	out := "_" + strings.Repeat("___", m.Width()) + "\n"
 	for y := 0; y < m.Height(); y++ {
 		str := ""
 		for x := 0; x < m.Width(); x++ {
@@ -132,13 +195,11 @@ func PrintMaze(m MazeI) {
 			}
 			r, err := m.GetRoom(x, y)
 			if err != nil {
-				fmt.Println(err)
-				os.Exit(-1)
+				return err
 			}
 			s, err := m.Discover(x, y)
 			if err != nil {
-				fmt.Println(err)
-				os.Exit(-1)
+				return err
 			}
 			if s.Bottom {
 				if r.Treasure {
@@ -165,14 +226,23 @@ func PrintMaze(m MazeI) {
 			}
 
 		}
-		fmt.Println(str)
+		out += str + "\n"
 	}
+
+	_, err := io.WriteString(w, out)
+	return err
 }
 
 // PrintPrettyMaze prints the maze in a pretty format, which makes
 // debugging build issues so much easier. Courtesy of Kim Eik
 // (https://gist.github.com/netbrain/63ad3c3743d5ca5e9869)
 func PrintPrettyMaze(m MazeI) {
+	WritePrettyMaze(os.Stdout, m)
+}
+
+// WritePrettyMaze writes the maze to w in the same format used by
+// PrintPrettyMaze
+func WritePrettyMaze(w io.Writer, m MazeI) error {
 	out := ""
 	str := make([][]string, m.Height()*3)
 	for i := 0; i < m.Height(); i++ {
@@ -232,7 +302,8 @@ func PrintPrettyMaze(m MazeI) {
 		out += "\n"
 	}
 
-	fmt.Println(out)
+	_, err := io.WriteString(w, out+"\n")
+	return err
 }
 
 // Delta returns the required displacement in order to go in direction
@@ -252,6 +323,83 @@ func Delta(dir int) (dx, dy int) {
 	return dx, dy
 }
 
+// DirectionName returns the name used by the server for direction dir
+func DirectionName(dir int) string {
+	switch dir {
+	case N:
+		return "up"
+	case S:
+		return "down"
+	case E:
+		return "right"
+	case W:
+		return "left"
+	}
+
+	return ""
+}
+
+// ParseDirection returns the direction corresponding to name, as used
+// by the server
+func ParseDirection(name string) (int, error) {
+	switch name {
+	case "up":
+		return N, nil
+	case "down":
+		return S, nil
+	case "right":
+		return E, nil
+	case "left":
+		return W, nil
+	}
+
+	return 0, errors.New("invalid direction")
+}
+
+// ParsePath returns the directions in the compact path p, where each
+// direction is written as a single letter: U (up), D (down), L (left)
+// or R (right), in either case.
+func ParsePath(p string) ([]int, error) {
+	dirs := make([]int, 0, len(p))
+
+	for _, c := range p {
+		switch c {
+		case 'U', 'u':
+			dirs = append(dirs, N)
+		case 'D', 'd':
+			dirs = append(dirs, S)
+		case 'R', 'r':
+			dirs = append(dirs, E)
+		case 'L', 'l':
+			dirs = append(dirs, W)
+		default:
+			return nil, fmt.Errorf("invalid direction %q in path", c)
+		}
+	}
+
+	return dirs, nil
+}
+
+// FormatPath returns path in the compact form read by ParsePath
+func FormatPath(path []int) string {
+	var b strings.Builder
+
+	for _, dir := range path {
+		switch dir {
+		case N:
+			b.WriteByte('U')
+		case S:
+			b.WriteByte('D')
+		case E:
+			b.WriteByte('R')
+		case W:
+			b.WriteByte('L')
+		}
+	}
+
+	return b.String()
+}
+
 // Shift takes input coordinates (x, y) and returns displaced
 // coordinates in direction dir
 func Shift(x, y, dir int) (int, int) {
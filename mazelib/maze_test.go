@@ -0,0 +1,50 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormatPath(t *testing.T) {
+	path := []int{N, S, E, W, E, E, N}
+
+	p := FormatPath(path)
+	if p != "UDRLRRU" {
+		t.Errorf("formatted %v as %q, expecting %q", path, p, "UDRLRRU")
+	}
+
+	got, err := ParsePath(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, path) {
+		t.Errorf("parsed %q as %v, expecting %v", p, got, path)
+	}
+
+	if got, err := ParsePath("udrl"); err != nil || FormatPath(got) != "UDRL" {
+		t.Errorf("parsed %q as %v, %v", "udrl", got, err)
+	}
+
+	if _, err := ParsePath("UDX"); err == nil {
+		t.Error("invalid path accepted")
+	}
+
+	if p := FormatPath(nil); p != "" {
+		t.Errorf("formatted empty path as %q", p)
+	}
+}
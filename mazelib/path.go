@@ -0,0 +1,79 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+// ShortestPath returns the directions to follow in order to go from
+// room from to room to in maze m using as few steps as possible, or nil
+// if there's no way to get there.
+func ShortestPath(m MazeI, from, to Coordinate) []int {
+	type step struct {
+		from Coordinate
+		dir  int
+	}
+
+	w, h := m.Width(), m.Height()
+	if !Valid(from.X, from.Y, w, h) || !Valid(to.X, to.Y, w, h) {
+		return nil
+	}
+
+	prev := map[Coordinate]step{}
+	seen := map[Coordinate]bool{from: true}
+	queue := []Coordinate{from}
+
+	for len(queue) > 0 && !seen[to] {
+		c := queue[0]
+		queue = queue[1:]
+
+		s, err := m.Discover(c.X, c.Y)
+		if err != nil {
+			continue
+		}
+
+		for _, dir := range []int{N, S, E, W} {
+			if s.Wall(dir) {
+				continue
+			}
+			x, y := Shift(c.X, c.Y, dir)
+			n := Coordinate{x, y}
+			if seen[n] || !Valid(x, y, w, h) {
+				continue
+			}
+			seen[n] = true
+			prev[n] = step{c, dir}
+			queue = append(queue, n)
+		}
+	}
+
+	if !seen[to] {
+		return nil
+	}
+
+	var path []int
+	for c := to; c != from; c = prev[c].from {
+		path = append(path, prev[c].dir)
+	}
+
+	// the path was built backwards
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	if path == nil {
+		path = []int{}
+	}
+
+	return path
+}
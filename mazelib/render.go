@@ -0,0 +1,172 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import "image/color"
+
+// RenderOptions tells the renderers what to draw on top of the walls,
+// the start and the treasure, and how. The zero value draws just the
+// maze using the default size and colors.
+type RenderOptions struct {
+	// CellSize is the size of each room, in pixels
+	CellSize int
+
+	// Optimal draws the shortest path from the start to the treasure
+	Optimal bool
+
+	// Trajectory is the path followed by Icarus from the start,
+	// drawn unless it's empty
+	Trajectory []int
+
+	// Heatmap shades each room according to how many times Icarus
+	// went through it following Trajectory
+	Heatmap bool
+
+	// Palette picks the colors to use. Colors left out are taken
+	// from DefaultPalette.
+	Palette Palette
+}
+
+// Palette picks the colors used to render a maze. Colors left nil are
+// taken from DefaultPalette, so that any color can be picked, even a
+// transparent one.
+type Palette struct {
+	Background *color.RGBA
+	Wall       *color.RGBA
+	Start      *color.RGBA
+	Treasure   *color.RGBA
+	Optimal    *color.RGBA
+	Trajectory *color.RGBA
+
+	// Heat is the color of the rooms visited most often. Rooms
+	// visited less often are lighter.
+	Heat *color.RGBA
+}
+
+// DefaultPalette holds the colors used unless RenderOptions.Palette
+// says otherwise
+var DefaultPalette = Palette{
+	Background: rgba(0xff, 0xff, 0xff),
+	Wall:       rgba(0x20, 0x20, 0x20),
+	Start:      rgba(0x2e, 0x7d, 0x32),
+	Treasure:   rgba(0xf9, 0xa8, 0x25),
+	Optimal:    rgba(0x43, 0xa0, 0x47),
+	Trajectory: rgba(0x1e, 0x88, 0xe5),
+	Heat:       rgba(0xe5, 0x39, 0x35),
+}
+
+// rgba returns a new opaque color
+func rgba(r, g, b uint8) *color.RGBA {
+	return &color.RGBA{r, g, b, 0xff}
+}
+
+// Resolve returns a copy of p with every color set, taking the missing
+// ones from DefaultPalette
+func (p Palette) Resolve() Palette {
+	pick := func(c, def *color.RGBA) *color.RGBA {
+		if c == nil {
+			c = def
+		}
+		picked := *c
+		return &picked
+	}
+
+	return Palette{
+		Background: pick(p.Background, DefaultPalette.Background),
+		Wall:       pick(p.Wall, DefaultPalette.Wall),
+		Start:      pick(p.Start, DefaultPalette.Start),
+		Treasure:   pick(p.Treasure, DefaultPalette.Treasure),
+		Optimal:    pick(p.Optimal, DefaultPalette.Optimal),
+		Trajectory: pick(p.Trajectory, DefaultPalette.Trajectory),
+		Heat:       pick(p.Heat, DefaultPalette.Heat),
+	}
+}
+
+// colors holds the colors the renderers draw with
+type colors struct {
+	Background color.RGBA
+	Wall       color.RGBA
+	Start      color.RGBA
+	Treasure   color.RGBA
+	Optimal    color.RGBA
+	Trajectory color.RGBA
+	Heat       color.RGBA
+}
+
+// newColors returns the colors picked by p
+func newColors(p Palette) colors {
+	p = p.Resolve()
+
+	return colors{
+		Background: *p.Background,
+		Wall:       *p.Wall,
+		Start:      *p.Start,
+		Treasure:   *p.Treasure,
+		Optimal:    *p.Optimal,
+		Trajectory: *p.Trajectory,
+		Heat:       *p.Heat,
+	}
+}
+
+// defaultCellSize is the size of the rooms, in pixels, if none is given
+const defaultCellSize = 20
+
+// withDefaults returns a copy of o with the missing values taken from
+// the defaults. The colors are resolved by newColors.
+func (o RenderOptions) withDefaults() RenderOptions {
+	if o.CellSize <= 0 {
+		o.CellSize = defaultCellSize
+	}
+
+	return o
+}
+
+// walk returns the rooms Icarus goes through following path from room
+// from, including that one
+func walk(from Coordinate, path []int) []Coordinate {
+	rooms := make([]Coordinate, 0, len(path)+1)
+	rooms = append(rooms, from)
+
+	for _, dir := range path {
+		x, y := Shift(from.X, from.Y, dir)
+		from = Coordinate{x, y}
+		rooms = append(rooms, from)
+	}
+
+	return rooms
+}
+
+// visits returns how many times each room of a maze of w×h rooms
+// appears in rooms, and the largest count
+func visits(rooms []Coordinate, w, h int) ([][]int, int) {
+	counts := make([][]int, h)
+	for y := range counts {
+		counts[y] = make([]int, w)
+	}
+
+	max := 0
+	for _, c := range rooms {
+		if !Valid(c.X, c.Y, w, h) {
+			continue
+		}
+		counts[c.Y][c.X]++
+		if counts[c.Y][c.X] > max {
+			max = counts[c.Y][c.X]
+		}
+	}
+
+	return counts, max
+}
@@ -0,0 +1,69 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestPaletteTransparent(t *testing.T) {
+	b := testBlueprints(t)[len(testSizes)*5-1]
+	m := newTestMaze(b.Width, b.Height)
+	if err := b.Build(m); err != nil {
+		t.Fatal(err)
+	}
+
+	o := RenderOptions{Palette: Palette{Background: &color.RGBA{}}}
+
+	img, err := RenderImage(m, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// inside a room away from the start and the treasure there's
+	// just background
+	var room Coordinate
+	for room == b.Start || room == b.Treasure {
+		room.X++
+	}
+	r, err := newRasterizer(m, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := r.corner(room.X, room.Y).Add(image.Pt(r.cell/4, r.cell/4))
+	if c := img.RGBAAt(p.X, p.Y); c != (color.RGBA{}) {
+		t.Errorf("background is %v, expecting it to be transparent", c)
+	}
+
+	// the colors that weren't picked are the default ones
+	corner := r.corner(0, 0)
+	wall := img.RGBAAt(corner.X, corner.Y)
+	if wall != *DefaultPalette.Wall {
+		t.Errorf("wall is %v, expecting %v", wall, *DefaultPalette.Wall)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, m, o); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `fill="#000000" fill-opacity="0.00"`) {
+		t.Error("SVG background isn't transparent")
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"io"
+	"strings"
+)
+
+// WriteSVG writes an SVG drawing of maze m to w. Besides the walls, the
+// start and the treasure, it draws the layers chosen in o. Each layer
+// has an id, in case the drawing needs tweaking: heatmap, optimal,
+// trajectory, walls, start and treasure.
+func WriteSVG(w io.Writer, m MazeI, o RenderOptions) error {
+	b, err := NewBlueprint(m, Info{})
+	if err != nil {
+		return err
+	}
+
+	o = o.withDefaults()
+	palette := newColors(o.Palette)
+
+	cell := float64(o.CellSize)
+	stroke := cell / 10
+	if stroke < 1 {
+		stroke = 1
+	}
+
+	// corner returns the position of the top left corner of room
+	// (x, y), and center the position of its center
+	corner := func(x, y int) (float64, float64) {
+		return stroke + float64(x)*cell, stroke + float64(y)*cell
+	}
+	center := func(c Coordinate) (float64, float64) {
+		x, y := corner(c.X, c.Y)
+		return x + cell/2, y + cell/2
+	}
+
+	bw := bufio.NewWriter(w)
+
+	width, height := 2*stroke+float64(b.Width)*cell, 2*stroke+float64(b.Height)*cell
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="0 0 %g %g">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(bw, `<rect width="%g" height="%g" %s/>`+"\n", width, height, svgPaint("fill", palette.Background))
+
+	if o.Heatmap && len(o.Trajectory) > 0 {
+		counts, max := visits(walk(b.Start, o.Trajectory), b.Width, b.Height)
+		fmt.Fprintf(bw, `<g id="heatmap" %s>`+"\n", svgPaint("fill", palette.Heat))
+		for y, row := range counts {
+			for x, n := range row {
+				if n == 0 {
+					continue
+				}
+				cx, cy := corner(x, y)
+				fmt.Fprintf(bw, `<rect x="%g" y="%g" width="%g" height="%g" fill-opacity="%.2f"/>`+"\n",
+					cx, cy, cell, cell, 0.15+0.85*float64(n)/float64(max))
+			}
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+
+	// polyline draws a line going through the center of rooms
+	polyline := func(id string, rooms []Coordinate, c color.RGBA, width float64) {
+		points := make([]string, len(rooms))
+		for i, r := range rooms {
+			x, y := center(r)
+			points[i] = fmt.Sprintf("%g,%g", x, y)
+		}
+		fmt.Fprintf(bw, `<polyline id="%s" points="%s" fill="none" %s stroke-width="%g" stroke-linecap="round" stroke-linejoin="round"/>`+"\n",
+			id, strings.Join(points, " "), svgPaint("stroke", c), width)
+	}
+
+	if o.Optimal {
+		if path := ShortestPath(m, b.Start, b.Treasure); path != nil {
+			polyline("optimal", walk(b.Start, path), palette.Optimal, cell/4)
+		}
+	}
+
+	if len(o.Trajectory) > 0 {
+		polyline("trajectory", walk(b.Start, o.Trajectory), palette.Trajectory, cell/8)
+	}
+
+	// the walls shared by two rooms are drawn only once: each room
+	// draws its top and left walls, and the rooms along the right and
+	// bottom edges draw those too
+	var walls strings.Builder
+	for y, row := range b.Walls {
+		for x, s := range row {
+			x0, y0 := corner(x, y)
+			if s.Top {
+				fmt.Fprintf(&walls, "M%g %gh%g", x0, y0, cell)
+			}
+			if s.Left {
+				fmt.Fprintf(&walls, "M%g %gv%g", x0, y0, cell)
+			}
+			if s.Right && x == b.Width-1 {
+				fmt.Fprintf(&walls, "M%g %gv%g", x0+cell, y0, cell)
+			}
+			if s.Bottom && y == b.Height-1 {
+				fmt.Fprintf(&walls, "M%g %gh%g", x0, y0+cell, cell)
+			}
+		}
+	}
+	fmt.Fprintf(bw, `<path id="walls" d="%s" fill="none" %s stroke-width="%g" stroke-linecap="square"/>`+"\n",
+		walls.String(), svgPaint("stroke", palette.Wall), stroke)
+
+	sx, sy := center(b.Start)
+	fmt.Fprintf(bw, `<circle id="start" cx="%g" cy="%g" r="%g" %s/>`+"\n", sx, sy, cell/4, svgPaint("fill", palette.Start))
+
+	tx, ty := center(b.Treasure)
+	r := cell / 3
+	fmt.Fprintf(bw, `<polygon id="treasure" points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" %s/>`+"\n",
+		tx, ty-r, tx+r, ty, tx, ty+r, tx-r, ty, svgPaint("fill", palette.Treasure))
+
+	fmt.Fprintln(bw, `</svg>`)
+
+	return bw.Flush()
+}
+
+// svgPaint returns the SVG attributes to paint attr, either fill or
+// stroke, with color c
+func svgPaint(attr string, c color.RGBA) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	s := fmt.Sprintf(`%s="#%02x%02x%02x"`, attr, n.R, n.G, n.B)
+	if n.A != 0xff {
+		s += fmt.Sprintf(` %s-opacity="%.2f"`, attr, float64(n.A)/0xff)
+	}
+	return s
+}
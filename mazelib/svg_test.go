@@ -0,0 +1,175 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+// corridor is a maze of three rooms in a row, with the start at the
+// west end and the treasure at the east end
+const corridor = `
+__________
+|S_____T_|
+`
+
+// drawnMaze builds the maze in drawing
+func drawnMaze(t *testing.T, drawing string) *testMaze {
+	b, err := ParseMaze(strings.NewReader(drawing))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTestMaze(b.Width, b.Height)
+	if err := b.Build(m); err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+// svgElement is an element of an SVG drawing, with all its attributes
+// and children
+type svgElement struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr   `xml:",any,attr"`
+	Children []svgElement `xml:",any"`
+}
+
+func (e *svgElement) attr(name string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// find returns the element with the given id, or nil
+func (e *svgElement) find(id string) *svgElement {
+	if e.attr("id") == id {
+		return e
+	}
+	for i := range e.Children {
+		if f := e.Children[i].find(id); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func parseSVG(t *testing.T, m MazeI, o RenderOptions) *svgElement {
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, m, o); err != nil {
+		t.Fatal(err)
+	}
+
+	var svg svgElement
+	if err := xml.Unmarshal(buf.Bytes(), &svg); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, buf.String())
+	}
+
+	return &svg
+}
+
+func TestSVGLayers(t *testing.T) {
+	m := drawnMaze(t, corridor)
+	trajectory := []int{E, W, E, E}
+
+	tests := []struct {
+		name   string
+		o      RenderOptions
+		layers []string
+	}{
+		{"maze", RenderOptions{}, nil},
+		{"optimal", RenderOptions{Optimal: true}, []string{"optimal"}},
+		{"trajectory", RenderOptions{Trajectory: trajectory}, []string{"trajectory"}},
+		{"heatmap", RenderOptions{Trajectory: trajectory, Heatmap: true}, []string{"heatmap", "trajectory"}},
+		{"heatmap without trajectory", RenderOptions{Heatmap: true}, nil},
+		{"all", RenderOptions{Optimal: true, Trajectory: trajectory, Heatmap: true}, []string{"heatmap", "optimal", "trajectory"}},
+	}
+
+	for _, test := range tests {
+		svg := parseSVG(t, m, test.o)
+
+		want := map[string]bool{"walls": true, "start": true, "treasure": true}
+		for _, id := range test.layers {
+			want[id] = true
+		}
+		for _, id := range []string{"heatmap", "optimal", "trajectory", "walls", "start", "treasure"} {
+			if found := svg.find(id) != nil; found != want[id] {
+				t.Errorf("%s: layer %s drawn: %v, expecting %v", test.name, id, found, want[id])
+			}
+		}
+	}
+}
+
+func TestSVGPaths(t *testing.T) {
+	m := drawnMaze(t, corridor)
+
+	// rooms are 10 pixels wide and the walls 1 pixel thick, so the
+	// centers of the rooms are 6, 16 and 26 pixels from the edge
+	svg := parseSVG(t, m, RenderOptions{CellSize: 10, Optimal: true, Trajectory: []int{E, W, E, E}, Heatmap: true})
+
+	if w, h := svg.attr("width"), svg.attr("height"); w != "32" || h != "12" {
+		t.Errorf("size %sx%s, expecting 32x12", w, h)
+	}
+
+	tests := []struct {
+		id, points string
+	}{
+		{"optimal", "6,6 16,6 26,6"},
+		{"trajectory", "6,6 16,6 6,6 16,6 26,6"},
+	}
+	for _, test := range tests {
+		if e := svg.find(test.id); e == nil || e.attr("points") != test.points {
+			t.Errorf("%s: points %+v, expecting %q", test.id, e, test.points)
+		}
+	}
+
+	// every room was visited, the first two twice as often as the last
+	heatmap := svg.find("heatmap")
+	if heatmap == nil || len(heatmap.Children) != 3 {
+		t.Fatalf("heatmap %+v, expecting three rooms", heatmap)
+	}
+	for i, want := range []string{"1.00", "1.00", "0.57"} {
+		if got := heatmap.Children[i].attr("fill-opacity"); got != want {
+			t.Errorf("room %d: heat %s, expecting %s", i, got, want)
+		}
+	}
+}
+
+func TestSVGPaint(t *testing.T) {
+	tests := []struct {
+		c    color.RGBA
+		want string
+	}{
+		{color.RGBA{0x11, 0x22, 0x33, 0xff}, `fill="#112233"`},
+		{color.RGBA{}, `fill="#000000" fill-opacity="0.00"`},
+		// colors are premultiplied by their alpha
+		{color.RGBA{0x40, 0x20, 0x00, 0x80}, `fill="#7f3f00" fill-opacity="0.50"`},
+	}
+
+	for _, test := range tests {
+		if got := svgPaint("fill", test.c); got != test.want {
+			t.Errorf("%v: %s, expecting %s", test.c, got, test.want)
+		}
+	}
+}
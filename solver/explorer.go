@@ -0,0 +1,462 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+// Package solver implements maze solvers that only rely on what Icarus
+// can see from the room he is standing in.
+package solver
+
+import (
+	"github.com/mem/labyrinth/mazelib"
+)
+
+// Mover is implemented by anything that can move Icarus around a maze.
+// Move returns the survey of the room Icarus moved into, or
+// mazelib.ErrVictory if that room holds the treasure.
+type Mover interface {
+	Move(dir int) (mazelib.Survey, error)
+}
+
+// PathMover is implemented by movers that can move Icarus several steps
+// at once. MovePath returns the surveys of the rooms Icarus moved into,
+// and stops at the first move that fails, returning its error.
+type PathMover interface {
+	Mover
+	MovePath(path []int) ([]mazelib.Survey, error)
+}
+
+// Bounds describes a rectangular region of the maze, in coordinates
+// relative to the room where Icarus woke up.
+type Bounds struct {
+	MinX, MinY, MaxX, MaxY int
+}
+
+// Contains returns true if c is inside the bounds
+func (b Bounds) Contains(c mazelib.Coordinate) bool {
+	return c.X >= b.MinX && c.X <= b.MaxX && c.Y >= b.MinY && c.Y <= b.MaxY
+}
+
+// dirs lists the directions in the order the explorer considers them.
+// Like the recursive solver, it has a right and bottom bias.
+var dirs = []int{mazelib.E, mazelib.S, mazelib.W, mazelib.N}
+
+// Explorer solves mazes by building a map of the rooms it has seen.
+// Icarus doesn't know the size of the maze, nor where he is in it, so
+// the explorer keeps track of the region known to exist (every room
+// visited and every room behind an open door) and uses the walls seen
+// at the edges of that region to guess whether the maze extends any
+// further.
+//
+// Every step the explorer walks to the closest room it hasn't visited
+// yet using the known passages, all at once if the mover is a
+// PathMover. Visited rooms that can't possibly lead to unexplored rooms
+// are filled in (dead-end filling), so they are never walked into
+// again. When several rooms are equally close, the explorer prefers the
+// one that opens into the largest unexplored region: the rooms it can
+// reach without going through visited ones, skipping everything beyond
+// the guessed external walls. The guess only breaks ties, so a wrong
+// one costs steps but never hides a room.
+type Explorer struct {
+	m      Mover
+	cur    mazelib.Coordinate
+	rooms  map[mazelib.Coordinate]mazelib.Survey
+	filled map[mazelib.Coordinate]bool
+	extent Bounds
+
+	// last is where Icarus was the last time a room was recorded
+	last mazelib.Coordinate
+
+	// cols and rows count the visited rooms in each column and row
+	cols, rows map[int]int
+
+	// doors counts, for each direction, the visited rooms in each
+	// column (for E and W) or row (for N and S) with a door in that
+	// direction
+	doors map[int]map[int]int
+}
+
+// NewExplorer creates a new explorer that moves Icarus using m
+func NewExplorer(m Mover) *Explorer {
+	return &Explorer{
+		m:      m,
+		rooms:  make(map[mazelib.Coordinate]mazelib.Survey),
+		filled: make(map[mazelib.Coordinate]bool),
+		cols:   make(map[int]int),
+		rows:   make(map[int]int),
+		doors: map[int]map[int]int{
+			mazelib.N: make(map[int]int),
+			mazelib.S: make(map[int]int),
+			mazelib.E: make(map[int]int),
+			mazelib.W: make(map[int]int),
+		},
+	}
+}
+
+// Solve explores the maze starting from the room described by s until
+// the treasure is found or there are no more rooms to visit. It
+// returns true if the treasure was found.
+func (e *Explorer) Solve(s mazelib.Survey) bool {
+	e.record(s)
+
+	for {
+		path := e.nextPath()
+		if path == nil {
+			return false
+		}
+
+		t, err := e.follow(path)
+		switch err {
+		case nil:
+		case mazelib.ErrVictory:
+			return true
+		default:
+			return false
+		}
+
+		e.record(t)
+	}
+}
+
+// follow moves Icarus along path and returns the survey of the room at
+// the end of it. If the mover can follow whole paths at once, it's
+// used to do so.
+func (e *Explorer) follow(path []int) (mazelib.Survey, error) {
+	var s mazelib.Survey
+
+	if pm, ok := e.m.(PathMover); ok && len(path) > 1 {
+		surveys, err := pm.MovePath(path)
+		for _, dir := range path[:len(surveys)] {
+			e.cur.X, e.cur.Y = mazelib.Shift(e.cur.X, e.cur.Y, dir)
+		}
+		if len(surveys) > 0 {
+			s = surveys[len(surveys)-1]
+		}
+		return s, err
+	}
+
+	for _, dir := range path {
+		t, err := e.m.Move(dir)
+		if err != nil {
+			return s, err
+		}
+		e.cur.X, e.cur.Y = mazelib.Shift(e.cur.X, e.cur.Y, dir)
+		s = t
+	}
+
+	return s, nil
+}
+
+// Extent returns the region of the maze known to exist so far
+func (e *Explorer) Extent() Bounds {
+	return e.extent
+}
+
+// Bounds returns a guess of the region of the maze that might exist
+// given what has been seen so far. Sides of the known extent where
+// every visited room has an outer wall are taken to be the external
+// walls of the maze; the other sides might extend at least one more
+// room. Since those walls might as well be internal ones, this is just
+// an estimate, and the maze can be larger.
+func (e *Explorer) Bounds() Bounds {
+	b := e.extent
+	if !e.closed(mazelib.W) {
+		b.MinX--
+	}
+	if !e.closed(mazelib.E) {
+		b.MaxX++
+	}
+	if !e.closed(mazelib.N) {
+		b.MinY--
+	}
+	if !e.closed(mazelib.S) {
+		b.MaxY++
+	}
+	return b
+}
+
+// record adds the room Icarus is currently standing in to the map
+func (e *Explorer) record(s mazelib.Survey) {
+	if len(e.rooms) == 0 {
+		e.extent = Bounds{e.cur.X, e.cur.Y, e.cur.X, e.cur.Y}
+		e.last = e.cur
+	}
+
+	if _, ok := e.rooms[e.cur]; !ok {
+		e.cols[e.cur.X]++
+		e.rows[e.cur.Y]++
+		for _, dir := range dirs {
+			if !wall(s, dir) {
+				e.doors[dir][e.line(e.cur, dir)]++
+			}
+		}
+	}
+	e.rooms[e.cur] = s
+
+	e.grow(e.cur)
+	for _, dir := range dirs {
+		if !wall(s, dir) {
+			x, y := mazelib.Shift(e.cur.X, e.cur.Y, dir)
+			e.grow(mazelib.Coordinate{X: x, Y: y})
+		}
+	}
+
+	// only the rooms next to the new one and the one Icarus left
+	// behind can have become dead ends
+	candidates := []mazelib.Coordinate{e.last}
+	for _, dir := range dirs {
+		x, y := mazelib.Shift(e.cur.X, e.cur.Y, dir)
+		candidates = append(candidates, mazelib.Coordinate{X: x, Y: y})
+	}
+	e.fill(candidates)
+
+	e.last = e.cur
+}
+
+// grow extends the known extent to include c
+func (e *Explorer) grow(c mazelib.Coordinate) {
+	if c.X < e.extent.MinX {
+		e.extent.MinX = c.X
+	}
+	if c.X > e.extent.MaxX {
+		e.extent.MaxX = c.X
+	}
+	if c.Y < e.extent.MinY {
+		e.extent.MinY = c.Y
+	}
+	if c.Y > e.extent.MaxY {
+		e.extent.MaxY = c.Y
+	}
+}
+
+// closed returns true if the side dir of the known extent looks like
+// an external wall, that is, at least one room along that side has been
+// visited and all the visited ones have a wall facing out. The walls
+// might be internal ones, so this is a guess.
+func (e *Explorer) closed(dir int) bool {
+	var i, visited int
+	switch dir {
+	case mazelib.W:
+		i, visited = e.extent.MinX, e.cols[e.extent.MinX]
+	case mazelib.E:
+		i, visited = e.extent.MaxX, e.cols[e.extent.MaxX]
+	case mazelib.N:
+		i, visited = e.extent.MinY, e.rows[e.extent.MinY]
+	case mazelib.S:
+		i, visited = e.extent.MaxY, e.rows[e.extent.MaxY]
+	}
+
+	return visited > 0 && e.doors[dir][i] == 0
+}
+
+// line returns the column of room c if dir is E or W, or its row
+// otherwise
+func (e *Explorer) line(c mazelib.Coordinate, dir int) int {
+	if dir == mazelib.E || dir == mazelib.W {
+		return c.X
+	}
+	return c.Y
+}
+
+// fill marks as filled the dead ends among the candidate rooms: visited
+// rooms where all the doors lead to visited rooms and at most one of
+// them leads to a room that is not filled. Walking into such a room
+// can't lead to anything new, so the search skips them. Filling a room
+// can turn its neighbors into dead ends, so those are checked next. The
+// room Icarus is in is never filled because that's where the search
+// starts from.
+func (e *Explorer) fill(candidates []mazelib.Coordinate) {
+	for len(candidates) > 0 {
+		c := candidates[len(candidates)-1]
+		candidates = candidates[:len(candidates)-1]
+
+		if !e.deadEnd(c) {
+			continue
+		}
+
+		e.filled[c] = true
+
+		s := e.rooms[c]
+		for _, dir := range dirs {
+			if !wall(s, dir) {
+				x, y := mazelib.Shift(c.X, c.Y, dir)
+				candidates = append(candidates, mazelib.Coordinate{X: x, Y: y})
+			}
+		}
+	}
+}
+
+// deadEnd returns true if c is a visited room that can be filled
+func (e *Explorer) deadEnd(c mazelib.Coordinate) bool {
+	s, ok := e.rooms[c]
+	if !ok || c == e.cur || e.filled[c] {
+		return false
+	}
+
+	open := 0
+	for _, dir := range dirs {
+		if wall(s, dir) {
+			continue
+		}
+		x, y := mazelib.Shift(c.X, c.Y, dir)
+		n := mazelib.Coordinate{X: x, Y: y}
+		if _, ok := e.rooms[n]; !ok {
+			return false
+		}
+		if !e.filled[n] {
+			open++
+		}
+	}
+
+	return open <= 1
+}
+
+// nextPath returns the directions Icarus has to follow in order to get
+// to the next room to explore, or nil if there are no rooms left.
+func (e *Explorer) nextPath() []int {
+	type step struct {
+		from mazelib.Coordinate
+		dir  int
+	}
+
+	prev := map[mazelib.Coordinate]step{}
+	dist := map[mazelib.Coordinate]int{e.cur: 0}
+	queue := []mazelib.Coordinate{e.cur}
+
+	var best mazelib.Coordinate
+	bestDist, bestArea := -1, -1
+	var regions *regions
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+
+		if bestDist >= 0 && dist[c] >= bestDist {
+			// everything else is farther away
+			break
+		}
+
+		for _, dir := range dirs {
+			if wall(e.rooms[c], dir) {
+				continue
+			}
+			x, y := mazelib.Shift(c.X, c.Y, dir)
+			n := mazelib.Coordinate{X: x, Y: y}
+			if _, ok := dist[n]; ok || e.filled[n] {
+				continue
+			}
+			dist[n] = dist[c] + 1
+			prev[n] = step{c, dir}
+
+			if _, ok := e.rooms[n]; ok {
+				queue = append(queue, n)
+				continue
+			}
+
+			// n hasn't been visited, it's a candidate; the size of
+			// its region is only needed to break ties
+			switch {
+			case bestDist < 0:
+				best, bestDist = n, dist[n]
+			case bestArea < 0:
+				regions = e.newRegions()
+				bestArea = regions.area(best)
+				fallthrough
+			default:
+				if a := regions.area(n); a > bestArea {
+					best, bestArea = n, a
+				}
+			}
+		}
+	}
+
+	if bestDist < 0 {
+		return nil
+	}
+
+	path := make([]int, bestDist)
+	for c := best; c != e.cur; c = prev[c].from {
+		path[dist[c]-1] = prev[c].dir
+	}
+
+	return path
+}
+
+// regions splits the rooms inside the guessed bounds that haven't been
+// visited yet into regions: sets of rooms that can be reached from one
+// another without going through a visited room. Rooms beyond the
+// guessed external walls can't hold anything to explore, so they are
+// left out. Regions are labeled the first time they are needed.
+type regions struct {
+	e     *Explorer
+	b     Bounds
+	w     int
+	label []int // index of the region each room is in, plus one
+	sizes []int
+}
+
+func (e *Explorer) newRegions() *regions {
+	b := e.Bounds()
+	w := b.MaxX - b.MinX + 1
+	return &regions{e: e, b: b, w: w, label: make([]int, w*(b.MaxY-b.MinY+1))}
+}
+
+// index returns the position of room c in the labels
+func (r *regions) index(c mazelib.Coordinate) int {
+	return (c.Y-r.b.MinY)*r.w + c.X - r.b.MinX
+}
+
+// area returns the number of rooms in the region room c is in
+func (r *regions) area(c mazelib.Coordinate) int {
+	if l := r.label[r.index(c)]; l > 0 {
+		return r.sizes[l-1]
+	}
+
+	r.sizes = append(r.sizes, 0)
+	l := len(r.sizes)
+	r.label[r.index(c)] = l
+
+	queue := []mazelib.Coordinate{c}
+	for i := 0; i < len(queue); i++ {
+		for _, dir := range dirs {
+			x, y := mazelib.Shift(queue[i].X, queue[i].Y, dir)
+			n := mazelib.Coordinate{X: x, Y: y}
+			if !r.b.Contains(n) || r.label[r.index(n)] > 0 {
+				continue
+			}
+			if _, ok := r.e.rooms[n]; ok {
+				continue
+			}
+			r.label[r.index(n)] = l
+			queue = append(queue, n)
+		}
+	}
+
+	r.sizes[l-1] = len(queue)
+	return len(queue)
+}
+
+// wall returns true if survey s has a wall in direction dir
+func wall(s mazelib.Survey, dir int) bool {
+	switch dir {
+	case mazelib.N:
+		return s.Top
+	case mazelib.S:
+		return s.Bottom
+	case mazelib.E:
+		return s.Right
+	case mazelib.W:
+		return s.Left
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+// Copyright © 2015 Marcelo E. Magallon <marcelo.magallon@gmail.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package solver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mem/labyrinth/mazelib"
+)
+
+// testMover moves Icarus around a maze described by a blueprint
+type testMover struct {
+	b     *mazelib.Blueprint
+	at    mazelib.Coordinate
+	steps int
+	seen  map[mazelib.Coordinate]bool
+}
+
+func newTestMover(t *testing.T, drawing string) (*testMover, mazelib.Survey) {
+	b, err := mazelib.ParseMaze(strings.NewReader(drawing))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &testMover{b: b, at: b.Start, seen: map[mazelib.Coordinate]bool{b.Start: true}}
+	return m, b.Walls[b.Start.Y][b.Start.X]
+}
+
+func (m *testMover) Move(dir int) (mazelib.Survey, error) {
+	s := m.b.Walls[m.at.Y][m.at.X]
+	if s.Wall(dir) {
+		return s, mazelib.ErrWall
+	}
+
+	x, y := mazelib.Shift(m.at.X, m.at.Y, dir)
+	if !mazelib.Valid(x, y, m.b.Width, m.b.Height) {
+		return s, mazelib.ErrOutOfBounds
+	}
+
+	m.at = mazelib.Coordinate{X: x, Y: y}
+	m.steps++
+	m.seen[m.at] = true
+
+	if m.at == m.b.Treasure {
+		return m.b.Walls[y][x], mazelib.ErrVictory
+	}
+
+	return m.b.Walls[y][x], nil
+}
+
+// testPathMover is a testMover that can follow whole paths
+type testPathMover struct {
+	*testMover
+}
+
+func (m testPathMover) MovePath(path []int) ([]mazelib.Survey, error) {
+	var surveys []mazelib.Survey
+	for _, dir := range path {
+		s, err := m.Move(dir)
+		if err != nil && err != mazelib.ErrVictory {
+			return surveys, err
+		}
+		surveys = append(surveys, s)
+		if err != nil {
+			return surveys, err
+		}
+	}
+	return surveys, nil
+}
+
+var testMazes = map[string]string{
+	"corridor": `
+___________________
+|S______________T_|
+`,
+	"open": `
+________________
+|  _  _  _  _  |
+|  _S _  _  _  |
+|  _  _  _  _  |
+|____________T_|
+`,
+	"spiral": `
+________________
+|S___________  |
+|  _______  |  |
+|  |  _T_|  |  |
+|  |________|  |
+|______________|
+`,
+	"loops": `
+___________________
+|S |  _  |  _  _  |
+|  |___  |___  _  |
+|___  |  _  _  |  |
+|_______________T_|
+`,
+	"pockets": `
+_________________________
+|___  |___  |___  |  _  |
+|S__  ____  ____  _  _  |
+|_____|_____|_____|___T_|
+`,
+}
+
+func TestExplorerSolves(t *testing.T) {
+	for name, drawing := range testMazes {
+		m, s := newTestMover(t, drawing)
+		if !NewExplorer(m).Solve(s) {
+			t.Errorf("%s: treasure not found", name)
+			continue
+		}
+		if m.at != m.b.Treasure {
+			t.Errorf("%s: Icarus is at %v, expecting %v", name, m.at, m.b.Treasure)
+		}
+	}
+}
+
+func TestExplorerCorridor(t *testing.T) {
+	m, s := newTestMover(t, testMazes["corridor"])
+	NewExplorer(m).Solve(s)
+	if m.steps != 5 {
+		t.Errorf("%d steps along a corridor, expecting 5", m.steps)
+	}
+}
+
+func TestExplorerPathMover(t *testing.T) {
+	for name, drawing := range testMazes {
+		m1, s := newTestMover(t, drawing)
+		NewExplorer(m1).Solve(s)
+
+		m2, s := newTestMover(t, drawing)
+		if !NewExplorer(testPathMover{m2}).Solve(s) {
+			t.Errorf("%s: treasure not found following paths", name)
+			continue
+		}
+
+		if m1.steps != m2.steps {
+			t.Errorf("%s: %d steps following paths, expecting %d", name, m2.steps, m1.steps)
+		}
+	}
+}
+
+func TestExplorerUnreachable(t *testing.T) {
+	m, s := newTestMover(t, `
+__________
+|S _  ___|
+|_____|T_|
+`)
+
+	if NewExplorer(m).Solve(s) {
+		t.Fatal("found a treasure that can't be reached")
+	}
+
+	// all the reachable rooms were visited before giving up
+	if len(m.seen) != 5 {
+		t.Errorf("visited %d rooms, expecting 5", len(m.seen))
+	}
+}
+
+func TestExplorerExtent(t *testing.T) {
+	m, s := newTestMover(t, testMazes["open"])
+	e := NewExplorer(m)
+	e.Solve(s)
+
+	// coordinates are relative to the start, at (1, 1) in a 5x4 maze,
+	// and the extent only covers rooms known to exist
+	maze := Bounds{MinX: -1, MinY: -1, MaxX: 3, MaxY: 2}
+	x := e.Extent()
+	if !x.Contains(mazelib.Coordinate{}) {
+		t.Errorf("extent %+v doesn't cover the start", x)
+	}
+	if x.MinX < maze.MinX || x.MinY < maze.MinY || x.MaxX > maze.MaxX || x.MaxY > maze.MaxY {
+		t.Errorf("extent %+v is larger than the maze", x)
+	}
+}
+
+func TestExplorerNextPath(t *testing.T) {
+	// the corridor along the middle row splits the rest of the maze in
+	// two, and going down from the start shows that the bottom row is
+	// the last one, so there's more left to explore above
+	branches := `
+________________
+| __ __T__ __  |
+|S _ __ __ __  |
+|______________|
+`
+
+	testcases := []struct {
+		name    string
+		drawing string
+		path    []int
+		bounds  Bounds
+		dir     int
+	}{
+		{
+			name:    "start",
+			drawing: testMazes["corridor"],
+			bounds:  Bounds{MinX: 0, MinY: 0, MaxX: 2, MaxY: 0},
+			dir:     mazelib.E,
+		},
+		{
+			name:    "largest region",
+			drawing: branches,
+			path:    []int{mazelib.S, mazelib.N, mazelib.E, mazelib.E, mazelib.E, mazelib.E},
+			bounds:  Bounds{MinX: 0, MinY: -2, MaxX: 4, MaxY: 1},
+			dir:     mazelib.N,
+		},
+		{
+			name:    "equal regions",
+			drawing: branches,
+			path:    []int{mazelib.E, mazelib.E, mazelib.E, mazelib.E},
+			bounds:  Bounds{MinX: 0, MinY: -2, MaxX: 4, MaxY: 2},
+			dir:     mazelib.S,
+		},
+	}
+
+	for _, tc := range testcases {
+		m, s := newTestMover(t, tc.drawing)
+		e := NewExplorer(m)
+		e.record(s)
+		for _, dir := range tc.path {
+			s, err := e.follow([]int{dir})
+			if err != nil {
+				t.Fatalf("%s: moving %d: %v", tc.name, dir, err)
+			}
+			e.record(s)
+		}
+
+		if b := e.Bounds(); b != tc.bounds {
+			t.Errorf("%s: bounds %+v, expecting %+v", tc.name, b, tc.bounds)
+		}
+
+		path := e.nextPath()
+		if len(path) != 1 || path[0] != tc.dir {
+			t.Errorf("%s: path %v, expecting [%d]", tc.name, path, tc.dir)
+		}
+	}
+}